@@ -1,9 +1,5 @@
 package jsonpath
 
-import (
-	"github.com/pkg/errors"
-)
-
 type jsonAction interface {
 	Execute(ctx *evalContext) (jsonNode, error)
 }
@@ -11,152 +7,224 @@ type jsonAction interface {
 var (
 	_ jsonAction = arrayIndexAction(0)
 	_ jsonAction = arrayIndexListAction{}
+	_ jsonAction = arraySliceAction{}
 	_ jsonAction = arrayFieldAccessAction{}
 	_ jsonAction = fieldAccessAction("")
 	_ jsonAction = rootAccessAction{}
 	_ jsonAction = recursiveAction{}
+	_ jsonAction = wildcardAccessAction{}
+	_ jsonAction = filterAction{}
+	_ jsonAction = unionAction{}
 )
 
 type arrayIndexAction int
 
 func (a arrayIndexAction) Execute(ctx *evalContext) (jsonNode, error) {
-	if isArray(ctx.data) {
-		items := make(jsonArray, 0)
-		for _, item := range ctx.data.(jsonArray) {
-			if !isArray(item) {
-				return nil, errors.Errorf("item is not an array")
-			}
-			// TODO (elliotcourant) What should we do with ok here?
-			result, _ := getIndex(item, int(a))
-			items = append(items, result)
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0)
+	for _, item := range nodes {
+		if err := ctx.cancel.check(); err != nil {
+			return nil, err
 		}
 
-		return items, nil
+		// Indexes only select the elements of arrays, any other kind of node
+		// does not have anything to select.
+		if !isArray(item.value) {
+			continue
+		}
+
+		if result, index, ok := getIndex(item.value, int(a)); ok {
+			items = append(items, locatedNode{
+				value:    result,
+				location: item.location.child(index),
+			})
+		}
 	}
 
-	return nil, errors.Errorf("item is not an array")
+	return items, nil
 }
 
 type arrayIndexListAction []int
 
-func newArrayIndexListAction(indexes []integerToken) arrayIndexListAction {
+func newArrayIndexListAction(indexes []int) arrayIndexListAction {
 	a := make(arrayIndexListAction, len(indexes), len(indexes))
-	for i, index := range indexes {
-		a[i] = int(index)
-	}
+	copy(a, indexes)
 
 	return a
 }
 
 func (a arrayIndexListAction) Execute(ctx *evalContext) (jsonNode, error) {
-	if isArray(ctx.data) {
-		data := ctx.data.(jsonArray)
-		items := make(jsonArray, 0, len(a)*len(data))
-		for _, item := range data {
-			if !isArray(item) {
-				return nil, errors.Errorf("item is not an array")
-			}
-			// TODO (elliotcourant) What should we do with ok here?
-			for _, index := range a {
-				result, _ := getIndex(item, index)
-				items = append(items, result)
-			}
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0, len(a)*len(nodes))
+	for _, item := range nodes {
+		if err := ctx.cancel.check(); err != nil {
+			return nil, err
 		}
 
-		return items, nil
+		if !isArray(item.value) {
+			continue
+		}
+
+		for _, index := range a {
+			if result, index, ok := getIndex(item.value, index); ok {
+				items = append(items, locatedNode{
+					value:    result,
+					location: item.location.child(index),
+				})
+			}
+		}
 	}
 
-	return nil, errors.Errorf("item is not an array")
+	return items, nil
 }
 
-type arrayFieldAccessAction []string
+type arraySliceAction struct {
+	start, end *int
+	step       int
+}
 
-func (a arrayFieldAccessAction) Execute(ctx *evalContext) (jsonNode, error) {
-	items := make([]interface{}, 0)
-	for _, field := range a {
-		action := fieldAccessAction(field)
-		item, err := action.Execute(ctx)
-		if err != nil {
+func newArraySliceAction(slice SliceSelector) arraySliceAction {
+	action := arraySliceAction{
+		start: slice.Start,
+		end:   slice.End,
+		step:  1,
+	}
+	if slice.Step != nil {
+		action.step = *slice.Step
+	}
+
+	return action
+}
+
+func (a arraySliceAction) Execute(ctx *evalContext) (jsonNode, error) {
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0)
+	for _, item := range nodes {
+		if err := ctx.cancel.check(); err != nil {
 			return nil, err
 		}
 
-		if array, ok := item.(jsonArray); ok {
-			items = append(items, array...)
-		} else {
-			items = append(items, item)
+		length, ok := arrayLength(item.value)
+		if !ok {
+			continue
+		}
+
+		lower, upper := a.bounds(length)
+		switch {
+		case a.step > 0:
+			for i := lower; i < upper; i += a.step {
+				items = append(items, locatedNode{
+					value:    elementAt(item.value, i),
+					location: item.location.child(i),
+				})
+			}
+		case a.step < 0:
+			for i := upper; lower < i; i += a.step {
+				items = append(items, locatedNode{
+					value:    elementAt(item.value, i),
+					location: item.location.child(i),
+				})
+			}
 		}
 	}
 
 	return items, nil
 }
 
-type fieldAccessAction string
+// bounds will return the lower and upper bounds of the slice for an array of
+// the provided length. When the step is positive the lower bound is inclusive
+// and the upper bound is exclusive, when it is negative this is reversed.
+func (a arraySliceAction) bounds(length int) (lower, upper int) {
+	normalize := func(index int) int {
+		if index < 0 {
+			return length + index
+		}
 
-func (f fieldAccessAction) Execute(ctx *evalContext) (jsonNode, error) {
-	if isObject(ctx.data) {
-		return f.extractField(ctx.data.(jsonObject))
+		return index
 	}
 
-	items := make([]interface{}, 0)
+	clamp := func(index, min, max int) int {
+		if index < min {
+			return min
+		}
+		if index > max {
+			return max
+		}
 
-	switch grouping := ctx.data.(type) {
-	case jsonArray:
-		for _, group := range grouping {
-			result, err := f.extractFromGroup(group)
-			if err != nil {
-				return nil, err
-			}
+		return index
+	}
 
-			items = append(items, result...)
+	if a.step >= 0 {
+		start, end := 0, length
+		if a.start != nil {
+			start = normalize(*a.start)
 		}
-	case jsonMutatedArray:
-		result, err := f.extractFromGroup(grouping)
-		if err != nil {
-			return nil, err
+		if a.end != nil {
+			end = normalize(*a.end)
 		}
 
-		items = append(items, result...)
+		return clamp(start, 0, length), clamp(end, 0, length)
+	}
 
+	start, end := length-1, -length-1
+	if a.start != nil {
+		start = normalize(*a.start)
+	}
+	if a.end != nil {
+		end = normalize(*a.end)
 	}
 
-	return items, nil
+	return clamp(end, -1, length-1), clamp(start, -1, length-1)
 }
 
-func (f fieldAccessAction) extractFromGroup(node jsonNode) ([]interface{}, error) {
-	items := make([]interface{}, 0)
-	switch obj := node.(type) {
-	case jsonObject:
-		item, err := f.extractField(obj)
-		if err != nil {
+type arrayFieldAccessAction []string
+
+func (a arrayFieldAccessAction) Execute(ctx *evalContext) (jsonNode, error) {
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0)
+	for _, node := range nodes {
+		if err := ctx.cancel.check(); err != nil {
 			return nil, err
 		}
 
-		if item == nil {
-			break
+		for _, field := range a {
+			if item, ok := fieldAccessAction(field).extractField(node); ok {
+				items = append(items, item)
+			}
 		}
+	}
 
-		items = append(items, item)
-	case jsonMutatedArray:
-		for _, group := range obj {
-			result, err := f.extractFromGroup(group)
-			if err != nil {
-				return nil, err
-			}
+	return items, nil
+}
+
+type fieldAccessAction string
 
-			items = append(items, result...)
+func (f fieldAccessAction) Execute(ctx *evalContext) (jsonNode, error) {
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0)
+	for _, node := range nodes {
+		if err := ctx.cancel.check(); err != nil {
+			return nil, err
+		}
+
+		if item, ok := f.extractField(node); ok {
+			items = append(items, item)
 		}
 	}
 
 	return items, nil
 }
 
-func (f fieldAccessAction) extractField(data jsonObject) (jsonNode, error) {
-	item, ok := data[string(f)]
+func (f fieldAccessAction) extractField(node locatedNode) (locatedNode, bool) {
+	item, ok := memberOf(node.value, string(f))
 	if !ok {
-		return nil, nil
+		return locatedNode{}, false
 	}
 
-	return item, nil
+	return locatedNode{
+		value:    item,
+		location: node.location.child(string(f)),
+	}, true
 }
 
 type rootAccessAction struct{}
@@ -177,65 +245,129 @@ func (r rootAccessAction) Execute(ctx *evalContext) (jsonNode, error) {
 type recursiveAction struct{}
 
 func (r recursiveAction) Execute(ctx *evalContext) (jsonNode, error) {
-	return r.getAllObjects(ctx.data)
-}
-
-func (r recursiveAction) getAllObjects(data jsonNode) (jsonMutatedArray, error) {
+	nodes, _ := nodesOf(ctx.data)
 	items := make(jsonMutatedArray, 0)
-	if isArray(data) {
-		for _, item := range data.(jsonArray) {
-			if isObject(item) || isArray(item) {
-				subItems, err := r.getAllObjects(item)
-				if err != nil {
-					return nil, err
-				}
-
-				items = append(items, subItems...)
-				items = append(items, item)
-			}
+	for _, item := range nodes {
+		var err error
+		if items, err = r.getAllObjects(ctx, items, item, 0); err != nil {
+			return nil, err
 		}
-	} else {
-		for _, item := range data.(jsonObject) {
-			if isObject(item) || isArray(item) {
-				subItems, err := r.getAllObjects(item)
-				if err != nil {
-					return nil, err
-				}
+	}
 
-				items = append(items, subItems...)
-				items = append(items, item)
-			}
-		}
+	return items, nil
+}
+
+// getAllObjects will append the provided node and all of the objects and
+// arrays within it to items. Each node is appended before its descendants.
+func (r recursiveAction) getAllObjects(ctx *evalContext, items jsonMutatedArray, node locatedNode, depth int) (jsonMutatedArray, error) {
+	if err := ctx.cancel.check(); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.limits.checkDepth(depth); err != nil {
+		return nil, err
+	}
+
+	// Check the number of nodes as they are collected, instead of after, so
+	// that a large document is not collected before the limit is exceeded.
+	if err := ctx.limits.checkResults(len(items) + 1); err != nil {
+		return nil, err
+	}
+
+	if !isArray(node.value) && !isObject(node.value) {
+		return items, nil
+	}
+
+	items = append(items, node)
+	_, err := forEachChild(node, func(child locatedNode) (bool, error) {
+		var err error
+		items, err = r.getAllObjects(ctx, items, child, depth+1)
+
+		return err == nil, err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return items, nil
 }
 
+// walk will call visit with the provided node and then each of the objects and
+// arrays within it in the same order as getAllObjects, without collecting
+// them first. Walking stops as soon as visit returns false or an error.
+func (r recursiveAction) walk(ctx *evalContext, node locatedNode, depth int, visit func(node locatedNode) (bool, error)) (bool, error) {
+	if err := ctx.cancel.check(); err != nil {
+		return false, err
+	}
+
+	if err := ctx.limits.checkDepth(depth); err != nil {
+		return false, err
+	}
+
+	if !isArray(node.value) && !isObject(node.value) {
+		return true, nil
+	}
+
+	if ok, err := visit(node); !ok || err != nil {
+		return ok, err
+	}
+
+	return forEachChild(node, func(child locatedNode) (bool, error) {
+		return r.walk(ctx, child, depth+1, visit)
+	})
+}
+
 type wildcardAccessAction struct{}
 
 func (w wildcardAccessAction) Execute(ctx *evalContext) (jsonNode, error) {
-	items := make([]interface{}, 0)
-	if isArray(ctx.data) {
-		for _, sub := range ctx.data.(jsonArray) {
-			if isArray(sub) {
-				for _, item := range sub.(jsonArray) {
-					items = append(items, item)
-				}
-			} else if isObject(sub) {
-				for _, value := range sub.(jsonObject) {
-					items = append(items, value)
-				}
-			}
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0)
+	for _, node := range nodes {
+		if err := ctx.cancel.check(); err != nil {
+			return nil, err
 		}
 
-		return items, nil
-	} else if isObject(ctx.data) {
-		for _, value := range ctx.data.(jsonObject) {
-			items = append(items, value)
-		}
+		items = appendChildren(items, node)
 	}
 
-	// TODO (elliotcourant) Implement object wildcard.
+	return items, nil
+}
+
+// appendChildren will append every element of an array or every value of an
+// object to items. Any other kind of node does not have children.
+func appendChildren(items nodeList, node locatedNode) nodeList {
+	forEachChild(node, func(child locatedNode) (bool, error) {
+		items = append(items, child)
+		return true, nil
+	})
 
-	return nil, nil
+	return items
+}
+
+// unionAction is used when a single segment has selectors of different kinds.
+// Each selector is applied to every node in order, and skips the nodes that it
+// does not apply to.
+type unionAction []jsonAction
+
+func (u unionAction) Execute(ctx *evalContext) (jsonNode, error) {
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0)
+	for _, node := range nodes {
+		if err := ctx.cancel.check(); err != nil {
+			return nil, err
+		}
+
+		nodeCtx := ctx.child(nodeList{node})
+		for _, action := range u {
+			result, err := action.Execute(nodeCtx)
+			if err != nil {
+				return nil, err
+			}
+
+			selected, _ := nodesOf(result)
+			items = append(items, selected...)
+		}
+	}
+
+	return items, nil
 }
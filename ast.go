@@ -0,0 +1,236 @@
+package jsonpath
+
+import (
+	"fmt"
+)
+
+type (
+	// Path is the parsed form of a jsonpath. It is made up of segments that
+	// are applied in order, starting at the root of the document. A Path can
+	// be inspected to determine what a jsonpath will do without evaluating it.
+	Path struct {
+		Segments []Segment
+
+		// Function is applied to all of the results of the segments, it is
+		// empty if the path does not end with a function.
+		Function Function
+	}
+
+	// Segment is a single step of a Path. Every selector of a segment is
+	// applied to each node that the previous segment produced. The concrete
+	// type of a Segment will either be a ChildSegment or a DescendantSegment.
+	Segment interface {
+		fmt.Stringer
+		// Selectors returns the selectors that the segment applies.
+		Selectors() []Selector
+		segment()
+	}
+
+	// ChildSegment applies its selectors to the direct children of each node.
+	// It is written as either `.name`, `.*` or `[<selectors>]`.
+	ChildSegment []Selector
+
+	// DescendantSegment applies its selectors to each node and all of that
+	// node's descendants (recursive decent). It is written as either `..name`,
+	// `..*` or `..[<selectors>]`.
+	DescendantSegment []Selector
+
+	// Selector picks children out of a single node. The concrete type of a
+	// Selector will be one of NameSelector, IndexSelector, SliceSelector,
+	// WildcardSelector or FilterSelector.
+	Selector interface {
+		fmt.Stringer
+		selector()
+	}
+
+	// NameSelector selects the member of an object with the given name.
+	NameSelector string
+
+	// IndexSelector selects the element of an array at the given index.
+	// Negative indexes are relative to the end of the array.
+	IndexSelector int
+
+	// SliceSelector selects a range of elements from an array. Start, End and
+	// Step are optional and will be nil if they were omitted. Omitted values
+	// take on their defaults when the slice is evaluated, which depend on the
+	// sign of the step.
+	SliceSelector struct {
+		Start *int
+		End   *int
+		Step  *int
+	}
+
+	// WildcardSelector selects every element of an array or every member of
+	// an object.
+	WildcardSelector struct{}
+
+	// FilterSelector selects the children of a node for which the expression
+	// evaluates to true.
+	FilterSelector struct {
+		Expression Expression
+	}
+
+	// Expression is a single part of a filter selector's logical expression.
+	// The concrete type of an Expression will be one of OrExpression,
+	// AndExpression, NotExpression, ComparisonExpression, QueryExpression or
+	// LiteralExpression.
+	Expression interface {
+		fmt.Stringer
+		expression()
+	}
+
+	// OrExpression is true if any of its expressions are true.
+	OrExpression []Expression
+
+	// AndExpression is true if all of its expressions are true.
+	AndExpression []Expression
+
+	// NotExpression negates the result of the inner expression.
+	NotExpression struct {
+		Expression Expression
+	}
+
+	// ComparisonExpression compares the values of two expressions. Both sides
+	// will either be a LiteralExpression or a singular QueryExpression.
+	ComparisonExpression struct {
+		Left     Expression
+		Operator ComparisonOperator
+		Right    Expression
+	}
+
+	// QueryExpression is a path that is evaluated within a filter. Relative
+	// queries start at the node being filtered (`@`), absolute queries start
+	// at the root of the document (`$`). When a query is used on its own it
+	// tests whether the query selects anything.
+	QueryExpression struct {
+		Relative bool
+		Segments []Segment
+	}
+
+	// LiteralExpression is a constant value within a filter. The value will
+	// be a string, int64, json.Number, bool or nil. Numbers that are not an
+	// int64, like decimals, are a json.Number of how they were written so that
+	// they are compared exactly. A float64 can also be used when building an
+	// expression.
+	LiteralExpression struct {
+		Value interface{}
+	}
+
+	// ComparisonOperator is the operator of a ComparisonExpression.
+	ComparisonOperator string
+)
+
+// The comparison operators that can be used within a filter expression.
+const (
+	Equal              = ComparisonOperator(equals)
+	NotEqual           = ComparisonOperator(notEquals)
+	LessThan           = ComparisonOperator(lessThan)
+	LessThanOrEqual    = ComparisonOperator(lessThanOrEqualTo)
+	GreaterThan        = ComparisonOperator(greaterThan)
+	GreaterThanOrEqual = ComparisonOperator(greaterThanOrEqualTo)
+)
+
+var (
+	_ Segment = ChildSegment{}
+	_ Segment = DescendantSegment{}
+
+	_ Selector = NameSelector("")
+	_ Selector = IndexSelector(0)
+	_ Selector = SliceSelector{}
+	_ Selector = WildcardSelector{}
+	_ Selector = FilterSelector{}
+
+	_ Expression = OrExpression{}
+	_ Expression = AndExpression{}
+	_ Expression = NotExpression{}
+	_ Expression = ComparisonExpression{}
+	_ Expression = QueryExpression{}
+	_ Expression = LiteralExpression{}
+)
+
+// Parse will parse the provided jsonpath into its Path. An error is returned
+// if the jsonpath is not valid.
+func Parse(path string) (*Path, error) {
+	return parseLimited(path, nil)
+}
+
+// Evaluator will compile the path into an Evaluator. An error is returned if
+// the path is not valid, which can happen if it was not created by Parse.
+func (p *Path) Evaluator(options ...Option) (*Evaluator, error) {
+	return newPathEvaluator(p, options)
+}
+
+// String returns the jsonpath in its canonical form. Parsing the result will
+// produce an equivalent Path.
+func (p *Path) String() string {
+	return canonicalFormatter.path(p)
+}
+
+// IsSingular returns true if the path can only ever select a single node. A
+// path is singular when it only uses child segments with a single name or
+// index selector.
+func (p *Path) IsSingular() bool {
+	return isSingular(p.Segments)
+}
+
+func (c ChildSegment) Selectors() []Selector      { return c }
+func (d DescendantSegment) Selectors() []Selector { return d }
+
+func (c ChildSegment) segment()      {}
+func (d DescendantSegment) segment() {}
+
+func (c ChildSegment) String() string {
+	return canonicalFormatter.segment(c)
+}
+
+func (d DescendantSegment) String() string {
+	return canonicalFormatter.segment(d)
+}
+
+func (n NameSelector) selector()     {}
+func (i IndexSelector) selector()    {}
+func (s SliceSelector) selector()    {}
+func (w WildcardSelector) selector() {}
+func (f FilterSelector) selector()   {}
+
+func (n NameSelector) String() string     { return canonicalFormatter.selector(n) }
+func (i IndexSelector) String() string    { return canonicalFormatter.selector(i) }
+func (s SliceSelector) String() string    { return canonicalFormatter.selector(s) }
+func (w WildcardSelector) String() string { return canonicalFormatter.selector(w) }
+func (f FilterSelector) String() string   { return canonicalFormatter.selector(f) }
+
+func (o OrExpression) expression()         {}
+func (a AndExpression) expression()        {}
+func (n NotExpression) expression()        {}
+func (c ComparisonExpression) expression() {}
+func (q QueryExpression) expression()      {}
+func (l LiteralExpression) expression()    {}
+
+func (o OrExpression) String() string         { return canonicalFormatter.expression(o) }
+func (a AndExpression) String() string        { return canonicalFormatter.expression(a) }
+func (n NotExpression) String() string        { return canonicalFormatter.expression(n) }
+func (c ComparisonExpression) String() string { return canonicalFormatter.expression(c) }
+func (q QueryExpression) String() string      { return canonicalFormatter.expression(q) }
+func (l LiteralExpression) String() string    { return canonicalFormatter.expression(l) }
+
+// IsSingular returns true if the query can only ever select a single node.
+func (q QueryExpression) IsSingular() bool {
+	return isSingular(q.Segments)
+}
+
+func isSingular(segments []Segment) bool {
+	for _, segment := range segments {
+		child, ok := segment.(ChildSegment)
+		if !ok || len(child) != 1 {
+			return false
+		}
+
+		switch child[0].(type) {
+		case NameSelector, IndexSelector:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
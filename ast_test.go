@@ -0,0 +1,167 @@
+package jsonpath
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleParse shows how a path can be checked for recursive decent before it
+// is evaluated.
+func ExampleParse() {
+	path, err := Parse("$.store..book[?(@.price < 10)].title")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, segment := range path.Segments {
+		if _, ok := segment.(DescendantSegment); ok {
+			fmt.Println("uses recursive decent")
+		}
+	}
+
+	fmt.Println(path)
+	// Output:
+	// uses recursive decent
+	// $['store']..['book'][?@['price'] < 10]['title']
+}
+
+func TestParsePath(t *testing.T) {
+	t.Run("segments", func(t *testing.T) {
+		path, err := Parse("$.store..book[0,-1:].title")
+		require.NoError(t, err)
+
+		start := -1
+		assert.Equal(t, &Path{
+			Segments: []Segment{
+				ChildSegment{NameSelector("store")},
+				DescendantSegment{NameSelector("book")},
+				ChildSegment{IndexSelector(0), SliceSelector{Start: &start}},
+				ChildSegment{NameSelector("title")},
+			},
+		}, path)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		path, err := Parse("$[?(@.price < 10 && !@.isbn)]")
+		require.NoError(t, err)
+
+		assert.Equal(t, &Path{
+			Segments: []Segment{
+				ChildSegment{
+					FilterSelector{
+						Expression: AndExpression{
+							ComparisonExpression{
+								Left: QueryExpression{
+									Relative: true,
+									Segments: []Segment{
+										ChildSegment{NameSelector("price")},
+									},
+								},
+								Operator: LessThan,
+								Right:    LiteralExpression{Value: int64(10)},
+							},
+							NotExpression{
+								Expression: QueryExpression{
+									Relative: true,
+									Segments: []Segment{
+										ChildSegment{NameSelector("isbn")},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}, path)
+	})
+
+	t.Run("descendant check", func(t *testing.T) {
+		path, err := Parse("$.a[*]..b")
+		require.NoError(t, err)
+
+		descendant := false
+		for _, segment := range path.Segments {
+			if _, ok := segment.(DescendantSegment); ok {
+				descendant = true
+			}
+		}
+		assert.True(t, descendant)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		paths := []string{
+			"$..",
+			"$[]",
+			"$[0",
+			"$[?10]",
+			"$[?@.* == 1]",
+			"$[?@.a == ]",
+			"$[- 1]",
+			"$.a$",
+		}
+
+		for _, path := range paths {
+			_, err := Parse(path)
+			assert.Error(t, err, path)
+		}
+	})
+}
+
+func TestPath_String(t *testing.T) {
+	paths := map[string]string{
+		"$":                            "$",
+		"firstName":                    "$['firstName']",
+		"$.store.book[*].author":       "$['store']['book'][*]['author']",
+		"$..author":                    "$..['author']",
+		"$..*":                         "$..[*]",
+		`$["a"][0,'b']`:                "$['a'][0,'b']",
+		"$..book[-1:]":                 "$..['book'][-1:]",
+		"$[1:5:2]":                     "$[1:5:2]",
+		"$[::-1]":                      "$[::-1]",
+		"$[?(@.isbn)]":                 "$[?@['isbn']]",
+		"$[?@.price<10]":               "$[?@['price'] < 10]",
+		"$[?@.price >= -1.5]":          "$[?@['price'] >= -1.5]",
+		"$[?@.price == 2.0]":           "$[?@['price'] == 2.0]",
+		"$[?@.a == $.b]":               "$[?@['a'] == $['b']]",
+		"$[?@.a == null || @.b]":       "$[?@['a'] == null || @['b']]",
+		"$[?(@.a || @.b) && @.c]":      "$[?(@['a'] || @['b']) && @['c']]",
+		"$[?!(@.a == true)]":           "$[?!(@['a'] == true)]",
+		"$[?!(@.a && @.b)]":            "$[?!(@['a'] && @['b'])]",
+		"$.null.true.false":            "$['null']['true']['false']",
+		"$[?@.a=='x' && (@.b || @.c)]": "$[?@['a'] == 'x' && (@['b'] || @['c'])]",
+	}
+
+	for input, expected := range paths {
+		path, err := Parse(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, path.String(), input)
+
+		// The canonical form should parse back into the same path.
+		reparsed, err := Parse(path.String())
+		require.NoError(t, err, input)
+		assert.Equal(t, path, reparsed, input)
+	}
+}
+
+func TestPath_IsSingular(t *testing.T) {
+	paths := map[string]bool{
+		"$":            true,
+		"$.a[0]['b']":  true,
+		"$.a[0,1]":     false,
+		"$.a[*]":       false,
+		"$..a":         false,
+		"$.a[1:2]":     false,
+		"$.a[?@.b]":    false,
+		"$['a','b'].c": false,
+	}
+
+	for input, expected := range paths {
+		path, err := Parse(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, path.IsSingular(), input)
+	}
+}
@@ -0,0 +1,147 @@
+package jsonpath
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Builder is used to construct a path programmatically instead of writing the
+// jsonpath as a string. Field names given to the builder are used as is, so
+// they do not need to be quoted or escaped. Each method returns a new Builder
+// and leaves the original unchanged, so a common prefix can be shared. Any
+// mistake made while building, such as a segment without any selectors, is
+// returned by Evaluator.
+//
+//	eval, err := jsonpath.Root().Child("a.b").Index(-1).Wildcard().Evaluator()
+type Builder struct {
+	segments []Segment
+	err      error
+}
+
+// Root returns a builder for the root of the document, `$`.
+func Root() Builder {
+	return Builder{}
+}
+
+// Child selects the fields with the provided names, `['name']`. If more than
+// one name is provided then each of them is selected. At least one name must
+// be provided.
+func (b Builder) Child(names ...string) Builder {
+	return b.Segment(ChildSegment(nameSelectors(names)))
+}
+
+// Index selects the elements of an array at the provided indexes, `[index]`.
+// Negative indexes are relative to the end of the array. At least one index
+// must be provided.
+func (b Builder) Index(indexes ...int) Builder {
+	selectors := make([]Selector, len(indexes))
+	for i, index := range indexes {
+		selectors[i] = IndexSelector(index)
+	}
+
+	return b.Segment(ChildSegment(selectors))
+}
+
+// Slice selects the elements of an array from start up to end, `[start:end:step]`.
+func (b Builder) Slice(start, end, step int) Builder {
+	return b.Segment(ChildSegment{
+		SliceSelector{
+			Start: &start,
+			End:   &end,
+			Step:  &step,
+		},
+	})
+}
+
+// Wildcard selects every element of an array or every member of an object,
+// `[*]`.
+func (b Builder) Wildcard() Builder {
+	return b.Segment(ChildSegment{WildcardSelector{}})
+}
+
+// Filter selects the children that the expression is true for, `[?expression]`.
+func (b Builder) Filter(expression Expression) Builder {
+	return b.Segment(ChildSegment{
+		FilterSelector{
+			Expression: expression,
+		},
+	})
+}
+
+// Descendant selects the fields with the provided names from the current node
+// and all of its descendants, `..['name']`. If no names are provided then
+// every descendant is selected, `..[*]`.
+func (b Builder) Descendant(names ...string) Builder {
+	if len(names) == 0 {
+		return b.Segment(DescendantSegment{WildcardSelector{}})
+	}
+
+	return b.Segment(DescendantSegment(nameSelectors(names)))
+}
+
+// Segment appends any segment to the path. This can be used to build segments
+// that combine different kinds of selectors. The segment must have at least
+// one selector.
+func (b Builder) Segment(segment Segment) Builder {
+	if segment == nil {
+		return b.fail(errors.Errorf("segment %d of %s is nil", len(b.segments)+1, b))
+	}
+
+	segments := make([]Segment, len(b.segments), len(b.segments)+1)
+	copy(segments, b.segments)
+
+	next := Builder{
+		segments: append(segments, segment),
+		err:      b.err,
+	}
+	if len(segment.Selectors()) == 0 {
+		return next.fail(errors.Errorf("segment %d of %s does not have any selectors", len(b.segments)+1, b))
+	}
+
+	return next
+}
+
+// fail returns a copy of the builder with the error, unless the builder has
+// already failed in which case the first error is kept.
+func (b Builder) fail(err error) Builder {
+	if b.err == nil {
+		b.err = err
+	}
+
+	return b
+}
+
+// Path returns the path that has been built.
+func (b Builder) Path() *Path {
+	segments := make([]Segment, len(b.segments))
+	copy(segments, b.segments)
+
+	return &Path{
+		Segments: segments,
+	}
+}
+
+// Evaluator will compile the path that has been built into an Evaluator. An
+// error is returned if the path is not valid, such as a filter that compares
+// a non-singular query, or if a mistake was made while building it.
+func (b Builder) Evaluator(options ...Option) (*Evaluator, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	return newPathEvaluator(b.Path(), options)
+}
+
+// String returns the path that has been built in its canonical form. Any
+// field names are quoted and escaped.
+func (b Builder) String() string {
+	return b.Path().String()
+}
+
+func nameSelectors(names []string) []Selector {
+	selectors := make([]Selector, len(names))
+	for i, name := range names {
+		selectors[i] = NameSelector(name)
+	}
+
+	return selectors
+}
@@ -0,0 +1,104 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		path := Root().Child("a.b").Index(-1).Slice(0, 5, 1).Wildcard().Descendant("id")
+		assert.Equal(t, "$['a.b'][-1][0:5:1][*]..['id']", path.String())
+	})
+
+	t.Run("quoting", func(t *testing.T) {
+		names := []string{
+			"it's",
+			`back\slash`,
+			`"double"`,
+			"new\nline",
+			"tab\tand\u0001control",
+			"emoji 🙂",
+			"",
+		}
+
+		for _, name := range names {
+			path := Root().Child(name)
+			parsed, err := Parse(path.String())
+			require.NoError(t, err, path.String())
+			assert.Equal(t, path.Path(), parsed, path.String())
+		}
+	})
+
+	t.Run("shared prefix", func(t *testing.T) {
+		base := Root().Child("store")
+		books := base.Child("book").Wildcard()
+		bicycle := base.Child("bicycle")
+
+		assert.Equal(t, "$['store']", base.String())
+		assert.Equal(t, "$['store']['book'][*]", books.String())
+		assert.Equal(t, "$['store']['bicycle']", bicycle.String())
+	})
+
+	t.Run("evaluate", func(t *testing.T) {
+		eval, err := Root().Child("store").Child("book").Index(0, -1).Child("title").Evaluator()
+		require.NoError(t, err)
+
+		result, err := eval.Evaluate([]byte(StoreJson))
+		require.NoError(t, err)
+		AssertResult(t, []I{
+			"Sayings of the Century",
+			"The Lord of the Rings",
+		}, result)
+	})
+
+	t.Run("descendant wildcard", func(t *testing.T) {
+		path := Root().Child("store").Descendant()
+		assert.Equal(t, "$['store']..[*]", path.String())
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		eval, err := Root().Child("store").Child("book").Filter(ComparisonExpression{
+			Left: QueryExpression{
+				Relative: true,
+				Segments: Root().Child("price").Path().Segments,
+			},
+			Operator: GreaterThan,
+			Right:    LiteralExpression{Value: int64(20)},
+		}).Child("title").Evaluator()
+		require.NoError(t, err)
+
+		result, err := eval.Evaluate([]byte(StoreJson))
+		require.NoError(t, err)
+		AssertResult(t, []I{
+			"The Lord of the Rings",
+		}, result)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Root().Child().Evaluator()
+		assert.Error(t, err)
+	})
+
+	t.Run("no selectors", func(t *testing.T) {
+		_, err := Root().Child("a").Index().Child("b").Evaluator()
+		assert.EqualError(t, err, "segment 2 of $['a'] does not have any selectors")
+
+		_, err = Root().Child().Index().Evaluator()
+		assert.EqualError(t, err, "segment 1 of $ does not have any selectors")
+
+		_, err = Root().Segment(DescendantSegment{}).Evaluator()
+		assert.EqualError(t, err, "segment 1 of $ does not have any selectors")
+
+		_, err = Root().Segment(nil).Evaluator()
+		assert.EqualError(t, err, "segment 1 of $ is nil")
+
+		// The prefix is still valid.
+		prefix := Root().Child("a")
+		prefix.Index()
+		_, err = prefix.Evaluator()
+		assert.NoError(t, err)
+	})
+}
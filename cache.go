@@ -0,0 +1,141 @@
+package jsonpath
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultCacheCapacity is the number of compiled jsonpaths that are kept by
+// the DefaultCache.
+const DefaultCacheCapacity = 512
+
+// DefaultCache is used by Jsonpath so that paths which are evaluated
+// repeatedly are only compiled once. Its capacity can be changed with
+// SetCapacity, a capacity of 0 will disable caching.
+var DefaultCache = NewCache(DefaultCacheCapacity)
+
+type (
+	// Cache keeps the most recently used Evaluators for jsonpaths so that they
+	// do not need to be parsed and compiled each time they are used. When the
+	// cache is full the least recently used Evaluator is removed. A Cache is
+	// safe for concurrent use, as are the Evaluators it returns.
+	Cache struct {
+		mutex    sync.Mutex
+		capacity int
+		entries  map[string]*list.Element
+		order    *list.List // Most recently used entries are at the front.
+		stats    CacheStats
+	}
+
+	// CacheStats are the counters of a Cache. Hits and misses are counted for
+	// each call to Cache.Get, jsonpaths that fail to compile are counted as a
+	// miss.
+	CacheStats struct {
+		Hits      uint64
+		Misses    uint64
+		Evictions uint64
+		Size      int
+		Capacity  int
+	}
+
+	cacheEntry struct {
+		path      string
+		evaluator *Evaluator
+	}
+)
+
+// NewCache creates a cache that will keep at most capacity Evaluators. If
+// the capacity is 0 or less then nothing will be cached.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get will return the Evaluator for the provided jsonpath. If the jsonpath is
+// not in the cache then it is compiled and added. An error is returned if the
+// jsonpath is not valid, invalid jsonpaths are not cached.
+func (c *Cache) Get(path string) (*Evaluator, error) {
+	c.mutex.Lock()
+	if element, ok := c.entries[path]; ok {
+		c.order.MoveToFront(element)
+		c.stats.Hits++
+		c.mutex.Unlock()
+
+		return element.Value.(*cacheEntry).evaluator, nil
+	}
+	c.stats.Misses++
+	c.mutex.Unlock()
+
+	// The path is compiled without holding the lock so that other paths can
+	// still be retrieved in the meantime.
+	evaluator, err := NewEvaluator(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Another caller might have compiled the same path while we were.
+	if element, ok := c.entries[path]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(*cacheEntry).evaluator, nil
+	}
+
+	if c.capacity > 0 {
+		c.entries[path] = c.order.PushFront(&cacheEntry{
+			path:      path,
+			evaluator: evaluator,
+		})
+		c.evict()
+	}
+
+	return evaluator, nil
+}
+
+// SetCapacity changes the number of Evaluators that the cache will keep. If
+// the cache holds more than the new capacity then the least recently used
+// Evaluators are removed.
+func (c *Cache) SetCapacity(capacity int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.capacity = capacity
+	c.evict()
+}
+
+// Stats returns the current counters of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.capacity
+
+	return stats
+}
+
+// Purge removes all of the Evaluators from the cache. The counters are not
+// reset.
+func (c *Cache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+// evict will remove the least recently used entries until the cache is
+// within its capacity. The mutex must be held by the caller.
+func (c *Cache) evict() {
+	for c.order.Len() > 0 && c.order.Len() > c.capacity {
+		element := c.order.Back()
+		c.order.Remove(element)
+		delete(c.entries, element.Value.(*cacheEntry).path)
+		c.stats.Evictions++
+	}
+}
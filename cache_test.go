@@ -0,0 +1,130 @@
+package jsonpath
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("hits and misses", func(t *testing.T) {
+		cache := NewCache(2)
+
+		first, err := cache.Get("$.firstName")
+		require.NoError(t, err)
+		second, err := cache.Get("$.firstName")
+		require.NoError(t, err)
+		assert.Same(t, first, second, "the evaluator should be reused")
+
+		assert.Equal(t, CacheStats{
+			Hits:     1,
+			Misses:   1,
+			Size:     1,
+			Capacity: 2,
+		}, cache.Stats())
+	})
+
+	t.Run("least recently used is evicted", func(t *testing.T) {
+		cache := NewCache(2)
+
+		a, _ := cache.Get("$.a")
+		_, _ = cache.Get("$.b")
+		_, _ = cache.Get("$.a") // $.b is now the least recently used.
+		_, _ = cache.Get("$.c")
+
+		stats := cache.Stats()
+		assert.EqualValues(t, 1, stats.Evictions)
+		assert.Equal(t, 2, stats.Size)
+
+		again, _ := cache.Get("$.a")
+		assert.Same(t, a, again, "$.a should not have been evicted")
+		assert.EqualValues(t, 2, cache.Stats().Hits)
+
+		_, _ = cache.Get("$.b")
+		assert.EqualValues(t, 4, cache.Stats().Misses, "$.b should have been evicted")
+	})
+
+	t.Run("invalid paths are not cached", func(t *testing.T) {
+		cache := NewCache(2)
+
+		eval, err := cache.Get(`"thing`)
+		assert.Error(t, err)
+		assert.Nil(t, eval)
+		assert.Equal(t, 0, cache.Stats().Size)
+		assert.EqualValues(t, 1, cache.Stats().Misses)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cache := NewCache(0)
+
+		eval, err := cache.Get("$.a")
+		require.NoError(t, err)
+		assert.NotNil(t, eval)
+		assert.Equal(t, 0, cache.Stats().Size)
+	})
+
+	t.Run("set capacity", func(t *testing.T) {
+		cache := NewCache(4)
+		for i := 0; i < 4; i++ {
+			_, err := cache.Get(fmt.Sprintf("$[%d]", i))
+			require.NoError(t, err)
+		}
+
+		cache.SetCapacity(1)
+		stats := cache.Stats()
+		assert.Equal(t, 1, stats.Size)
+		assert.EqualValues(t, 3, stats.Evictions)
+
+		cache.Purge()
+		assert.Equal(t, 0, cache.Stats().Size)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		cache := NewCache(8)
+
+		var group sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			group.Add(1)
+			go func(i int) {
+				defer group.Done()
+				for j := 0; j < 100; j++ {
+					eval, err := cache.Get(fmt.Sprintf("$.phoneNumbers[%d].type", (i+j)%12))
+					if assert.NoError(t, err) {
+						_, err = eval.Evaluate([]byte(TestJson))
+						assert.NoError(t, err)
+					}
+				}
+			}(i)
+		}
+		group.Wait()
+
+		stats := cache.Stats()
+		assert.EqualValues(t, 800, stats.Hits+stats.Misses)
+		assert.LessOrEqual(t, stats.Size, 8)
+	})
+
+	t.Run("default cache", func(t *testing.T) {
+		before := DefaultCache.Stats()
+		_, err := Jsonpath([]byte(TestJson), "$.address.city")
+		require.NoError(t, err)
+		_, err = Jsonpath([]byte(TestJson), "$.address.city")
+		require.NoError(t, err)
+
+		after := DefaultCache.Stats()
+		assert.Greater(t, after.Hits, before.Hits)
+	})
+}
+
+func TestMustCompile(t *testing.T) {
+	assert.NotPanics(t, func() {
+		eval := MustCompile("$.firstName")
+		assert.NotNil(t, eval)
+	})
+
+	assert.PanicsWithValue(t, `jsonpath: MustCompile('$[0'): unexpected 'eof' in brackets`, func() {
+		MustCompile("$[0")
+	})
+}
@@ -0,0 +1,325 @@
+package jsonpath
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// The major types of CBOR items, from RFC 8949.
+const (
+	cborUnsigned = iota
+	cborNegative
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+const (
+	// cborIndefinite is the additional information of an item whose length
+	// is not known, it is ended by cborBreak.
+	cborIndefinite = 31
+	cborBreak      = 0xff
+
+	cborPositiveBignum = 2
+	cborNegativeBignum = 3
+)
+
+// CBORDecoder is a Decoder for CBOR documents as defined by RFC 8949, so that
+// jsonpaths can be evaluated against CBOR without converting it to json. Maps
+// are decoded as objects, with integer and byte string keys converted to
+// strings. Byte strings are decoded as a []byte, and undefined is decoded as
+// nil. Tags are replaced by their content, except for bignums which are
+// decoded as numbers.
+var CBORDecoder Decoder = cborDecoder{}
+
+type cborDecoder struct{}
+
+func (cborDecoder) Decode(data []byte, numbers NumberMode) (interface{}, error) {
+	reader := &cborReader{
+		binaryReader: binaryReader{data: data},
+	}
+
+	value, err := reader.item(numbers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode cbor input")
+	}
+
+	if err := reader.finish(); err != nil {
+		return nil, errors.Wrap(err, "failed to decode cbor input")
+	}
+
+	return value, nil
+}
+
+type cborReader struct {
+	binaryReader
+}
+
+// head will read the major type and argument of the next item. The argument is
+// the value, length or tag of the item. If the item has an indefinite length
+// then indefinite is true.
+func (r *cborReader) head() (major byte, argument uint64, indefinite bool, err error) {
+	initial, err := r.readByte()
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	major, info := initial>>5, initial&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info <= 27:
+		argument, err = r.readUint(1 << (info - 24))
+		return major, argument, false, err
+	case info == cborIndefinite && major >= cborBytes && major != cborTag:
+		return major, 0, true, nil
+	default:
+		return 0, 0, false, errors.Errorf("invalid additional information %d at offset %d", info, r.offset-1)
+	}
+}
+
+// item will read the next item, decoding numbers using the provided mode.
+func (r *cborReader) item(numbers NumberMode) (jsonNode, error) {
+	start := r.offset
+	major, argument, indefinite, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		return uint64Node(argument, numbers), nil
+	case cborNegative:
+		if argument <= math.MaxInt64 {
+			return int64Node(-1-int64(argument), numbers), nil
+		}
+
+		value := new(big.Int).SetUint64(argument)
+		return bigIntNode(value.Neg(value).Sub(value, big.NewInt(1)), numbers), nil
+	case cborBytes:
+		return r.bytes(major, argument, indefinite)
+	case cborText:
+		text, err := r.bytes(major, argument, indefinite)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(text), nil
+	case cborArray:
+		return r.array(argument, indefinite, numbers)
+	case cborMap:
+		return r.object(argument, indefinite, numbers)
+	case cborTag:
+		return r.tag(argument, numbers)
+	default:
+		return r.simple(start, argument, indefinite, numbers)
+	}
+}
+
+// bytes will read the contents of a byte or text string. Indefinite strings
+// are made up of definite chunks of the same type.
+func (r *cborReader) bytes(major byte, length uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		return r.read(length)
+	}
+
+	data := make([]byte, 0)
+	for {
+		if r.offset < len(r.data) && r.data[r.offset] == cborBreak {
+			r.offset++
+			return data, nil
+		}
+
+		chunkMajor, chunkLength, chunkIndefinite, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+
+		if chunkMajor != major || chunkIndefinite {
+			return nil, errors.Errorf("invalid chunk in indefinite string at offset %d", r.offset)
+		}
+
+		chunk, err := r.read(chunkLength)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, chunk...)
+	}
+}
+
+// next returns true if there is another item in an array or map, reading the
+// break at the end of an indefinite one.
+func (r *cborReader) next(i, length uint64, indefinite bool) (bool, error) {
+	if !indefinite {
+		return i < length, nil
+	}
+
+	if r.offset >= len(r.data) {
+		return false, errors.Errorf("unexpected end of input at offset %d", r.offset)
+	}
+
+	if r.data[r.offset] == cborBreak {
+		r.offset++
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (r *cborReader) array(length uint64, indefinite bool, numbers NumberMode) (jsonNode, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	array := make(jsonArray, 0, r.capacity(length))
+	for i := uint64(0); ; i++ {
+		ok, err := r.next(i, length, indefinite)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return array, nil
+		}
+
+		item, err := r.item(numbers)
+		if err != nil {
+			return nil, err
+		}
+
+		array = append(array, item)
+	}
+}
+
+func (r *cborReader) object(length uint64, indefinite bool, numbers NumberMode) (jsonNode, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	object := make(jsonObject, r.capacity(length))
+	for i := uint64(0); ; i++ {
+		ok, err := r.next(i, length, indefinite)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return object, nil
+		}
+
+		// Keys are decoded as json numbers so that they are formatted
+		// exactly.
+		start := r.offset
+		key, err := r.item(JSONNumbers)
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := binaryKey(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key at offset %d", start)
+		}
+
+		if object[name], err = r.item(numbers); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// tag will read the content of a tag. Bignums are decoded as numbers and any
+// other tag is replaced by its content.
+func (r *cborReader) tag(tag uint64, numbers NumberMode) (jsonNode, error) {
+	if tag != cborPositiveBignum && tag != cborNegativeBignum {
+		if err := r.enter(); err != nil {
+			return nil, err
+		}
+		defer r.leave()
+
+		return r.item(numbers)
+	}
+
+	start := r.offset
+	major, length, indefinite, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+
+	if major != cborBytes {
+		return nil, errors.Errorf("bignum at offset %d must be a byte string", start)
+	}
+
+	data, err := r.bytes(major, length, indefinite)
+	if err != nil {
+		return nil, err
+	}
+
+	value := new(big.Int).SetBytes(data)
+	if tag == cborNegativeBignum {
+		value.Neg(value).Sub(value, big.NewInt(1))
+	}
+
+	if value.IsInt64() {
+		return int64Node(value.Int64(), numbers), nil
+	}
+
+	return bigIntNode(value, numbers), nil
+}
+
+// simple will decode the booleans, null, undefined and floats.
+func (r *cborReader) simple(start int, argument uint64, indefinite bool, numbers NumberMode) (jsonNode, error) {
+	if indefinite {
+		return nil, errors.Errorf("unexpected break at offset %d", start)
+	}
+
+	// The size of the argument is needed to tell simple values and floats
+	// apart.
+	switch info := r.data[start] & 0x1f; {
+	case info == 25:
+		return float64Node(float16ToFloat64(uint16(argument)), numbers), nil
+	case info == 26:
+		return float64Node(float64(math.Float32frombits(uint32(argument))), numbers), nil
+	case info == 27:
+		return float64Node(math.Float64frombits(argument), numbers), nil
+	}
+
+	switch argument {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	default:
+		return nil, errors.Errorf("unsupported simple value %d at offset %d", argument, start)
+	}
+}
+
+// float16ToFloat64 will convert an IEEE 754 half precision float.
+func float16ToFloat64(half uint16) float64 {
+	sign := 1.0
+	if half&0x8000 != 0 {
+		sign = -1
+	}
+
+	exponent, fraction := int(half>>10&0x1f), float64(half&0x3ff)
+	switch exponent {
+	case 0:
+		return sign * math.Ldexp(fraction, -24)
+	case 0x1f:
+		if fraction != 0 {
+			return math.NaN()
+		}
+
+		return math.Inf(int(sign))
+	default:
+		return sign * math.Ldexp(fraction+1024, exponent-25)
+	}
+}
@@ -0,0 +1,104 @@
+package jsonpath
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TelemetryCbor is {"device": "a", "readings": [{"t": 1, "v": 20.5}]}.
+const TelemetryCbor = "a26664657669636561616872656164696e677381a26174016176f94d20"
+
+func mustHex(t *testing.T, data string) []byte {
+	decoded, err := hex.DecodeString(strings.ReplaceAll(data, " ", ""))
+	require.NoError(t, err)
+
+	return decoded
+}
+
+func TestCBORDecoder(t *testing.T) {
+	t.Run("evaluate", func(t *testing.T) {
+		eval, err := NewEvaluator("$.readings[?@.v > 20].t", WithDecoder(CBORDecoder))
+		require.NoError(t, err)
+
+		result, err := eval.Evaluate(mustHex(t, TelemetryCbor))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{float64(1)}, result)
+	})
+
+	// The examples are from appendix A of RFC 8949.
+	huge, _ := new(big.Int).SetString("18446744073709551616", 10)
+	examples := []struct {
+		data     string
+		numbers  NumberMode
+		expected interface{}
+	}{
+		{"00", Float64Numbers, float64(0)},
+		{"1903e8", Float64Numbers, float64(1000)},
+		{"3903e7", Float64Numbers, float64(-1000)},
+		{"1bffffffffffffffff", JSONNumbers, json.Number("18446744073709551615")},
+		{"3bffffffffffffffff", JSONNumbers, json.Number("-18446744073709551616")},
+		{"c249010000000000000000", BigNumbers, huge},
+		{"c349010000000000000000", JSONNumbers, json.Number("-18446744073709551617")},
+		{"c24101", JSONNumbers, json.Number("1")},
+		{"f93c00", Float64Numbers, 1.0},
+		{"f90001", Float64Numbers, 5.960464477539063e-8},
+		{"f9c400", Float64Numbers, -4.0},
+		{"f97c00", JSONNumbers, math.Inf(1)},
+		{"fa47c35000", Float64Numbers, 100000.0},
+		{"fb3ff199999999999a", JSONNumbers, json.Number("1.1")},
+		{"f4", Float64Numbers, false},
+		{"f5", Float64Numbers, true},
+		{"f6", Float64Numbers, nil},
+		{"f7", Float64Numbers, nil},
+		{"4401020304", Float64Numbers, []byte{1, 2, 3, 4}},
+		{"5f42010243030405ff", Float64Numbers, []byte{1, 2, 3, 4, 5}},
+		{"6449455446", Float64Numbers, "IETF"},
+		{"7f657374726561646d696e67ff", Float64Numbers, "streaming"},
+		{"c074323031332d30332d32315432303a30343a30305a", Float64Numbers, "2013-03-21T20:04:00Z"},
+		{"9f018202039f0405ffff", Float64Numbers, []interface{}{float64(1), []interface{}{float64(2), float64(3)}, []interface{}{float64(4), float64(5)}}},
+		{"bf61610161629f0203ffff", Float64Numbers, map[string]interface{}{"a": float64(1), "b": []interface{}{float64(2), float64(3)}}},
+		{"a201020304", Float64Numbers, map[string]interface{}{"1": float64(2), "3": float64(4)}},
+	}
+	for _, example := range examples {
+		t.Run(example.data, func(t *testing.T) {
+			value, err := CBORDecoder.Decode(mustHex(t, example.data), example.numbers)
+			require.NoError(t, err)
+			assert.Equal(t, example.expected, value)
+		})
+	}
+
+	invalid := map[string]string{
+		"empty":            "",
+		"truncated":        "1903",
+		"reserved":         "1c",
+		"break":            "ff",
+		"trailing data":    "0000",
+		"array key":        "a18001",
+		"invalid chunk":    "5f6161ff",
+		"unclosed array":   "9f01",
+		"simple value":     "f0",
+		"bignum not bytes": "c201",
+		"too long":         "5a000fffff00",
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := CBORDecoder.Decode(mustHex(t, data), Float64Numbers)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("too deep", func(t *testing.T) {
+		_, err := CBORDecoder.Decode(mustHex(t, strings.Repeat("81", maxBinaryDepth+1)+"00"), Float64Numbers)
+		assert.Error(t, err)
+
+		_, err = CBORDecoder.Decode(mustHex(t, strings.Repeat("c1", maxBinaryDepth+1)+"00"), Float64Numbers)
+		assert.Error(t, err)
+	})
+}
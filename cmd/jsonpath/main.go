@@ -0,0 +1,276 @@
+// Command jsonpath evaluates jsonpaths against json read from files or stdin,
+// using the same semantics as the jsonpath package.
+//
+// Usage:
+//
+//	jsonpath [flags] PATH [FILE...]
+//	jsonpath [flags] -e PATH [-e PATH...] [FILE...]
+//
+// The output format is chosen with -o:
+//
+//	json   the matches of each file as a json array, or an object of arrays
+//	       keyed by path when there is more than one path (the default)
+//	lines  each match as compact json on its own line
+//	raw    like lines, but strings are written without quotes
+//	paths  the normalized path of each match
+//
+// The exit code is 0 if anything matched, 1 if nothing matched, 2 if a path or
+// the flags are not valid, 3 if the json could not be read or parsed, and 4 if
+// a path could not be evaluated against the json.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/elliotcourant/jsonpath"
+)
+
+// The exit codes of the command.
+const (
+	exitMatch   = 0
+	exitNoMatch = 1
+	exitUsage   = 2
+	exitInput   = 3
+	exitEval    = 4
+)
+
+// The output formats of the command.
+const (
+	formatJson  = "json"
+	formatLines = "lines"
+	formatRaw   = "raw"
+	formatPaths = "paths"
+)
+
+type (
+	// pathsFlag collects each -e flag.
+	pathsFlag []string
+
+	// command is a single run of the command.
+	command struct {
+		format     string
+		paths      []string
+		evaluators []*jsonpath.Evaluator
+		stdout     io.Writer
+		matched    bool
+	}
+)
+
+func (p *pathsFlag) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *pathsFlag) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run will run the command with the provided arguments and return its exit
+// code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("jsonpath", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jsonpath [flags] PATH [FILE...]")
+		fmt.Fprintln(stderr, "       jsonpath [flags] -e PATH [-e PATH...] [FILE...]")
+		flags.PrintDefaults()
+	}
+
+	var paths pathsFlag
+	flags.Var(&paths, "e", "a `path` to evaluate, can be repeated")
+	format := flags.String("o", formatJson, "the output `format`: json, lines, raw or paths")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	files := flags.Args()
+	if len(paths) == 0 {
+		if len(files) == 0 {
+			flags.Usage()
+			return exitUsage
+		}
+
+		paths, files = files[:1], files[1:]
+	}
+
+	switch *format {
+	case formatJson, formatLines, formatRaw, formatPaths:
+	default:
+		fmt.Fprintf(stderr, "jsonpath: unknown output format '%s'\n", *format)
+		return exitUsage
+	}
+
+	cmd := &command{
+		format: *format,
+		paths:  paths,
+		stdout: stdout,
+	}
+	for _, path := range paths {
+		eval, err := jsonpath.NewEvaluator(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonpath: invalid path '%s': %s\n", path, err)
+			return exitUsage
+		}
+
+		cmd.evaluators = append(cmd.evaluators, eval)
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, file := range files {
+		data, err := readInput(file, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %s: %s\n", file, err)
+			return exitInput
+		}
+
+		if err := cmd.evaluate(data); err != nil {
+			fmt.Fprintf(stderr, "jsonpath: %s: %s\n", file, err)
+			return exitEval
+		}
+	}
+
+	if !cmd.matched {
+		return exitNoMatch
+	}
+
+	return exitMatch
+}
+
+// readInput will read the file, or stdin if the file is "-", and make sure
+// that it is valid json.
+func readInput(file string, stdin io.Reader) ([]byte, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshalling into a raw message only validates the json, but returns
+	// an error that describes what is wrong with it.
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// evaluate will evaluate every path against the json and write the results.
+func (c *command) evaluate(data []byte) error {
+	if c.format == formatPaths {
+		return c.writePaths(data)
+	}
+
+	results := make([][]json.RawMessage, len(c.evaluators))
+	for i, eval := range c.evaluators {
+		matches, err := eval.EvaluateRaw(data)
+		if err != nil {
+			return err
+		}
+
+		// The raw matches keep the formatting of the input, so they are
+		// compacted to be written consistently.
+		for j, match := range matches {
+			var buffer bytes.Buffer
+			if err := json.Compact(&buffer, match); err != nil {
+				return err
+			}
+			matches[j] = buffer.Bytes()
+		}
+
+		c.matched = c.matched || len(matches) > 0
+		results[i] = matches
+	}
+
+	if c.format == formatJson {
+		return c.writeJson(results)
+	}
+
+	for _, matches := range results {
+		for _, match := range matches {
+			if err := c.writeLine(match); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeJson will write the matches as an array, or as an object of arrays
+// keyed by path if there is more than one path.
+func (c *command) writeJson(results [][]json.RawMessage) error {
+	var output interface{} = results[0]
+	if len(c.paths) > 1 {
+		byPath := map[string][]json.RawMessage{}
+		for i, path := range c.paths {
+			byPath[path] = results[i]
+		}
+		output = byPath
+	}
+
+	// Strings are written as they are, like the other formats, instead of
+	// escaping html characters.
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(output)
+}
+
+// writeLine will write a single match on its own line. Strings are unquoted
+// when the format is raw.
+func (c *command) writeLine(match json.RawMessage) error {
+	if c.format == formatRaw && len(match) > 0 && match[0] == '"' {
+		var str string
+		if err := json.Unmarshal(match, &str); err != nil {
+			return err
+		}
+
+		_, err := fmt.Fprintln(c.stdout, str)
+		return err
+	}
+
+	_, err := fmt.Fprintf(c.stdout, "%s\n", match)
+
+	return err
+}
+
+// writePaths will write the normalized path of each match.
+func (c *command) writePaths(data []byte) error {
+	for _, eval := range c.evaluators {
+		var writeErr error
+		err := eval.Walk(data, func(path string, _ interface{}) bool {
+			c.matched = true
+			_, writeErr = fmt.Fprintln(c.stdout, path)
+			return writeErr == nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+
+	return nil
+}
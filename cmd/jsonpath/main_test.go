@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJson = `{
+	"store": {
+		"book": [
+			{"title": "Sayings of the Century", "price": 8.95},
+			{"title": "Sword of Honour", "price": 12.990}
+		]
+	},
+	"id": 1234567890123456789
+}`
+
+func runCommand(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	var out, err bytes.Buffer
+	code = run(args, strings.NewReader(stdin), &out, &err)
+
+	return code, out.String(), err.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testJson, "$.store.book[*].price")
+		assert.Equal(t, exitMatch, code)
+		assert.Equal(t, "[\n  8.95,\n  12.990\n]\n", stdout)
+	})
+
+	t.Run("multiple paths", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testJson, "-e", "$.id", "-e", "$.missing")
+		assert.Equal(t, exitMatch, code)
+		assert.JSONEq(t, `{"$.id": [1234567890123456789], "$.missing": []}`, stdout)
+		assert.Contains(t, stdout, "1234567890123456789")
+	})
+
+	t.Run("lines", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testJson, "-o", "lines", "$..book[?@.price > 10]")
+		assert.Equal(t, exitMatch, code)
+		assert.Equal(t, `{"title":"Sword of Honour","price":12.990}`+"\n", stdout)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testJson, "-o", "raw", "-e", "$..title", "-e", "$.id")
+		assert.Equal(t, exitMatch, code)
+		assert.Equal(t, "Sayings of the Century\nSword of Honour\n1234567890123456789\n", stdout)
+	})
+
+	t.Run("paths", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testJson, "-o", "paths", "$..price")
+		assert.Equal(t, exitMatch, code)
+		assert.Equal(t, "$['store']['book'][0]['price']\n$['store']['book'][1]['price']\n", stdout)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		dir := t.TempDir()
+		first := filepath.Join(dir, "first.json")
+		second := filepath.Join(dir, "second.json")
+		require.NoError(t, os.WriteFile(first, []byte(`{"a": 1}`), 0o600))
+		require.NoError(t, os.WriteFile(second, []byte(`{"a": 2}`), 0o600))
+
+		code, stdout, _ := runCommand(t, "", "-o", "lines", "$.a", first, second)
+		assert.Equal(t, exitMatch, code)
+		assert.Equal(t, "1\n2\n", stdout)
+
+		code, _, stderr := runCommand(t, "", "$.a", filepath.Join(dir, "missing.json"))
+		assert.Equal(t, exitInput, code)
+		assert.Contains(t, stderr, "missing.json")
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		code, stdout, _ := runCommand(t, testJson, "-o", "lines", "$.missing")
+		assert.Equal(t, exitNoMatch, code)
+		assert.Empty(t, stdout)
+
+		code, _, _ = runCommand(t, testJson, "-o", "paths", "$.missing")
+		assert.Equal(t, exitNoMatch, code)
+
+		code, _, _ = runCommand(t, `{"a": {"b": 1}}`, "$.a[0]")
+		assert.Equal(t, exitNoMatch, code)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		code, _, stderr := runCommand(t, testJson, "$[")
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "invalid path '$['")
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		code, _, stderr := runCommand(t, `{"a":`, "$.a")
+		assert.Equal(t, exitInput, code)
+		assert.Contains(t, stderr, "jsonpath: -: ")
+	})
+
+	t.Run("html characters", func(t *testing.T) {
+		for _, format := range []string{"json", "lines", "raw"} {
+			code, stdout, _ := runCommand(t, `{"a": "<x>&"}`, "-o", format, "$.a")
+			assert.Equal(t, exitMatch, code, format)
+			assert.Contains(t, stdout, "<x>&", format)
+		}
+
+		code, stdout, _ := runCommand(t, `{"a": "<x>&"}`, "-e", "$.a", "-e", "$['<b>']")
+		assert.Equal(t, exitMatch, code)
+		assert.Equal(t, "{\n  \"$.a\": [\n    \"<x>&\"\n  ],\n  \"$['<b>']\": []\n}\n", stdout)
+	})
+
+	t.Run("evaluation errors", func(t *testing.T) {
+		code, _, stderr := runCommand(t, testJson, "-o", "paths", "$..price.sum()")
+		assert.Equal(t, exitEval, code)
+		assert.Contains(t, stderr, "does not have a location")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		code, _, stderr := runCommand(t, testJson)
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "usage: jsonpath")
+
+		code, _, stderr = runCommand(t, testJson, "-o", "yaml", "$")
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, "unknown output format 'yaml'")
+
+		code, _, _ = runCommand(t, testJson, "-x")
+		assert.Equal(t, exitUsage, code)
+	})
+}
@@ -0,0 +1,213 @@
+package jsonpath
+
+import (
+	"github.com/pkg/errors"
+)
+
+// compilePath will convert the parsed path into the actions that are run to
+// evaluate it.
+func compilePath(path *Path) (compiledJsonPath, error) {
+	actions := make([]jsonAction, 0, len(path.Segments)+1)
+	actions = append(actions, rootAccessAction{})
+
+	segments, err := compileSegments(path.Segments)
+	if err != nil {
+		return compiledJsonPath{}, err
+	}
+
+	raw, scannable := newRawPath(path)
+	compiled := compiledJsonPath{
+		actions:   append(actions, segments...),
+		raw:       raw,
+		scannable: scannable,
+	}
+
+	if path.Function != "" {
+		if !isFunction(string(path.Function)) {
+			return compiledJsonPath{}, errors.Errorf("unknown function %s()", path.Function)
+		}
+
+		// The function needs every result, so the path cannot be scanned.
+		compiled.function = &compiledFunction{
+			function: path.Function,
+			singular: path.IsSingular(),
+		}
+		compiled.scannable = false
+	}
+
+	return compiled, nil
+}
+
+func compileSegments(segments []Segment) ([]jsonAction, error) {
+	actions := make([]jsonAction, 0, len(segments))
+	for _, segment := range segments {
+		if _, ok := segment.(DescendantSegment); ok {
+			actions = append(actions, recursiveAction{})
+		}
+
+		action, err := compileSelectors(segment.Selectors())
+		if err != nil {
+			return nil, err
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// compileSelectors will create a single action for all of the selectors of a
+// segment. Segments that only select fields or only select indexes have their
+// own actions, any other combination of selectors becomes a unionAction.
+func compileSelectors(selectors []Selector) (jsonAction, error) {
+	switch len(selectors) {
+	case 0:
+		return nil, errors.Errorf("segment does not have any selectors")
+	case 1:
+		return compileSelector(selectors[0])
+	}
+
+	fields := make(arrayFieldAccessAction, 0, len(selectors))
+	indexes := make([]int, 0, len(selectors))
+	union := make(unionAction, 0, len(selectors))
+	for _, selector := range selectors {
+		switch s := selector.(type) {
+		case NameSelector:
+			fields = append(fields, string(s))
+		case IndexSelector:
+			indexes = append(indexes, int(s))
+		}
+
+		action, err := compileSelector(selector)
+		if err != nil {
+			return nil, err
+		}
+
+		union = append(union, action)
+	}
+
+	switch len(selectors) {
+	case len(fields):
+		return fields, nil
+	case len(indexes):
+		return newArrayIndexListAction(indexes), nil
+	default:
+		return union, nil
+	}
+}
+
+func compileSelector(selector Selector) (jsonAction, error) {
+	switch s := selector.(type) {
+	case NameSelector:
+		return fieldAccessAction(s), nil
+	case IndexSelector:
+		return arrayIndexAction(s), nil
+	case SliceSelector:
+		return newArraySliceAction(s), nil
+	case WildcardSelector:
+		return wildcardAccessAction{}, nil
+	case FilterSelector:
+		expression, err := compileFilterExpression(s.Expression)
+		if err != nil {
+			return nil, err
+		}
+
+		return filterAction{
+			expression: expression,
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported selector %T", selector)
+	}
+}
+
+func compileFilterExpression(expression Expression) (filterExpression, error) {
+	switch e := expression.(type) {
+	case OrExpression:
+		filter := make(orFilter, len(e))
+		for i, inner := range e {
+			compiled, err := compileFilterExpression(inner)
+			if err != nil {
+				return nil, err
+			}
+
+			filter[i] = compiled
+		}
+
+		return filter, nil
+	case AndExpression:
+		filter := make(andFilter, len(e))
+		for i, inner := range e {
+			compiled, err := compileFilterExpression(inner)
+			if err != nil {
+				return nil, err
+			}
+
+			filter[i] = compiled
+		}
+
+		return filter, nil
+	case NotExpression:
+		compiled, err := compileFilterExpression(e.Expression)
+		if err != nil {
+			return nil, err
+		}
+
+		return notFilter{
+			expression: compiled,
+		}, nil
+	case ComparisonExpression:
+		left, err := compileFilterValue(e.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := compileFilterValue(e.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return comparisonFilter{
+			left:     left,
+			right:    right,
+			operator: comparisonToken(e.Operator),
+		}, nil
+	case QueryExpression:
+		return compileQuery(e)
+	default:
+		return nil, errors.Errorf("%s cannot be used as a filter", expression)
+	}
+}
+
+func compileFilterValue(expression Expression) (filterValue, error) {
+	switch e := expression.(type) {
+	case QueryExpression:
+		if !e.IsSingular() {
+			return nil, errors.Errorf("cannot compare non-singular query %s", e)
+		}
+
+		return compileQuery(e)
+	case LiteralExpression:
+		// Number literals are kept as an int64 or a json.Number so that they
+		// can be compared exactly with numbers that are not decoded as
+		// float64.
+		return literalFilter{value: e.Value}, nil
+	default:
+		return nil, errors.Errorf("%s cannot be compared", expression)
+	}
+}
+
+func compileQuery(query QueryExpression) (queryFilter, error) {
+	actions := make([]jsonAction, 0, len(query.Segments)+1)
+	if !query.Relative {
+		actions = append(actions, rootAccessAction{})
+	}
+
+	segments, err := compileSegments(query.Segments)
+	if err != nil {
+		return queryFilter{}, err
+	}
+
+	return queryFilter{
+		actions: append(actions, segments...),
+	}, nil
+}
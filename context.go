@@ -0,0 +1,93 @@
+package jsonpath
+
+import (
+	"context"
+)
+
+// cancellationInterval is the number of times that a cancellation is checked
+// before the context is actually checked.
+const cancellationInterval = 256
+
+// cancellation allows an evaluation to be stopped by a context. It is checked
+// while the evaluation moves through the nodes of the document. A nil
+// cancellation can be used when the evaluation cannot be cancelled.
+type cancellation struct {
+	ctx   context.Context
+	done  <-chan struct{}
+	count int
+}
+
+// newCancellation returns a cancellation for the provided context, or nil if
+// the context can never be cancelled.
+func newCancellation(ctx context.Context) *cancellation {
+	done := ctx.Done()
+	if done == nil {
+		return nil
+	}
+
+	return &cancellation{
+		ctx:  ctx,
+		done: done,
+	}
+}
+
+// check will return the error of the context if it is done. The context is
+// only checked periodically so that this is cheap enough to be called for
+// every node.
+func (c *cancellation) check() error {
+	if c == nil {
+		return nil
+	}
+
+	c.count++
+	if c.count < cancellationInterval {
+		return nil
+	}
+	c.count = 0
+
+	select {
+	case <-c.done:
+		return c.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// EvaluateContext is like Evaluate but will stop evaluating the jsonpath and
+// return the context's error as soon as the context is done.
+func (e *Evaluator) EvaluateContext(ctx context.Context, data []byte) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if e.compiled.scannable {
+		items, err := e.scan(data, newCancellation(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		// The context is only checked periodically while scanning, so it
+		// could have been cancelled after the last check.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return items, nil
+	}
+
+	node, err := e.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	nodes, err := e.run(locatedNode{value: node}, newCancellation(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return valuesOf(nodes), nil
+}
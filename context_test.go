@@ -0,0 +1,94 @@
+package jsonpath
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeJson returns an array of objects with enough nodes that a cancellation
+// will be checked many times while evaluating it.
+func largeJson(size int) []byte {
+	items := make([]string, size)
+	for i := range items {
+		items[i] = `{"a": {"b": [1, 2, 3]}, "c": "d"}`
+	}
+
+	return []byte("[" + strings.Join(items, ",") + "]")
+}
+
+func TestEvaluator_EvaluateContext(t *testing.T) {
+	data := largeJson(1000)
+
+	t.Run("not cancelled", func(t *testing.T) {
+		result, err := MustCompile("$..b[0]").EvaluateContext(context.Background(), data)
+		require.NoError(t, err)
+		assert.Len(t, result, 1000)
+	})
+
+	t.Run("already cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := MustCompile("$..*").EvaluateContext(ctx, data)
+		assert.Equal(t, context.Canceled, err)
+		assert.Nil(t, result)
+
+		result, err = MustCompile("$[0].a").EvaluateContext(ctx, data)
+		assert.Equal(t, context.Canceled, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		result, err := MustCompile("$..*").EvaluateContext(ctx, data)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("cancelled while running", func(t *testing.T) {
+		node, err := parseJson(data)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		paths := []string{
+			"$..*",
+			"$.*.*",
+			"$[?@.c == 'd']",
+			"$[*]['a','c']",
+		}
+		for _, path := range paths {
+			result, err := MustCompile(path).run(locatedNode{value: node}, newCancellation(ctx))
+			assert.Equal(t, context.Canceled, err, path)
+			assert.Nil(t, result, path)
+		}
+	})
+
+	t.Run("cancelled while scanning", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		paths := []string{
+			"$.*.*",
+			"$[*].a.b[0]",
+			"$[999]['a','c']",
+			"$[*]['c']",
+		}
+		for _, path := range paths {
+			eval := MustCompile(path)
+			require.True(t, eval.compiled.scannable, path)
+
+			result, err := eval.scan(data, newCancellation(ctx))
+			assert.Equal(t, context.Canceled, err, path)
+			assert.Nil(t, result, path)
+		}
+	})
+}
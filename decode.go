@@ -0,0 +1,112 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNoMatch is returned when a single result is expected but the
+	// jsonpath did not match anything.
+	ErrNoMatch = errors.New("jsonpath did not match anything")
+
+	// ErrMultipleMatches is returned when a single result is expected but the
+	// jsonpath matched more than one value.
+	ErrMultipleMatches = errors.New("jsonpath matched more than one value")
+)
+
+// EvaluateInto will run the compiled jsonpath against the provided json and
+// decode the results into target using the rules of json.Unmarshal. If target
+// is a pointer to a slice then each result is decoded as an element of the
+// slice, otherwise the jsonpath must match exactly one value which is decoded
+// into target. Byte slices, such as json.RawMessage, are decoded as a single
+// value. To decode a single array into a slice use Get instead.
+func (e *Evaluator) EvaluateInto(data []byte, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.Errorf("target must be a non-nil pointer, got %T", target)
+	}
+
+	matches, err := e.rawMatches(data)
+	if err != nil {
+		return err
+	}
+
+	if isResultSlice(value.Elem().Type()) {
+		return decodeAll(matches, value.Elem())
+	}
+
+	return decodeOne(matches, target)
+}
+
+// Get will run the jsonpath against the provided json and decode the single
+// value that it matches as a T. ErrNoMatch or ErrMultipleMatches is returned
+// if the jsonpath does not match exactly one value.
+func Get[T any](eval *Evaluator, data []byte) (T, error) {
+	var result T
+	matches, err := eval.rawMatches(data)
+	if err != nil {
+		return result, err
+	}
+
+	err = decodeOne(matches, &result)
+
+	return result, err
+}
+
+// All will run the jsonpath against the provided json and decode each of the
+// values that it matches as a T. If the jsonpath does not match anything then
+// an empty slice is returned.
+func All[T any](eval *Evaluator, data []byte) ([]T, error) {
+	matches, err := eval.rawMatches(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]T, 0, len(matches))
+	if err = decodeAll(matches, reflect.ValueOf(&result).Elem()); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// isResultSlice returns true if each result should be decoded as an element of
+// the type. Byte slices, such as json.RawMessage, are decoded as a single
+// value.
+func isResultSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// decodeOne will decode the only match into target.
+func decodeOne(matches []json.RawMessage, target interface{}) error {
+	switch len(matches) {
+	case 0:
+		return ErrNoMatch
+	case 1:
+	default:
+		return errors.Wrapf(ErrMultipleMatches, "found %d matches", len(matches))
+	}
+
+	if err := json.Unmarshal(matches[0], target); err != nil {
+		return errors.Wrapf(err, "failed to decode match into %T", target)
+	}
+
+	return nil
+}
+
+// decodeAll will decode each of the matches into a new element of slice.
+func decodeAll(matches []json.RawMessage, slice reflect.Value) error {
+	elements := reflect.MakeSlice(slice.Type(), len(matches), len(matches))
+	for i, match := range matches {
+		element := elements.Index(i).Addr().Interface()
+		if err := json.Unmarshal(match, element); err != nil {
+			return errors.Wrapf(err, "failed to decode match %d into %T", i, element)
+		}
+	}
+	slice.Set(elements)
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBook struct {
+	Category string  `json:"category"`
+	Author   string  `json:"author"`
+	Title    string  `json:"title"`
+	Price    float64 `json:"price"`
+}
+
+func TestEvaluator_EvaluateInto(t *testing.T) {
+	t.Run("slice", func(t *testing.T) {
+		var titles []string
+		err := MustCompile("$.store.book[:2].title").EvaluateInto([]byte(StoreJson), &titles)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Sayings of the Century", "Sword of Honour"}, titles)
+	})
+
+	t.Run("single value", func(t *testing.T) {
+		var book testBook
+		err := MustCompile("$.store.book[-1]").EvaluateInto([]byte(StoreJson), &book)
+		require.NoError(t, err)
+		assert.Equal(t, "The Lord of the Rings", book.Title)
+		assert.Equal(t, 22.99, book.Price)
+	})
+
+	t.Run("raw message", func(t *testing.T) {
+		var raw json.RawMessage
+		err := MustCompile("$.expensive").EvaluateInto([]byte(StoreJson), &raw)
+		require.NoError(t, err)
+		assert.Equal(t, "10", string(raw))
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+		var titles []string
+		err := MustCompile("$..title").EvaluateInto([]byte(StoreJson), titles)
+		assert.EqualError(t, err, "target must be a non-nil pointer, got []string")
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		var prices []int
+		err := MustCompile("$..author").EvaluateInto([]byte(StoreJson), &prices)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to decode match 0 into *int")
+	})
+
+	t.Run("multiple matches", func(t *testing.T) {
+		var title string
+		err := MustCompile("$..title").EvaluateInto([]byte(StoreJson), &title)
+		assert.True(t, errors.Is(err, ErrMultipleMatches))
+	})
+
+	t.Run("decoder", func(t *testing.T) {
+		var names []string
+		err := MustCompile("$.services[*].name", WithDecoder(YAMLDecoder)).EvaluateInto([]byte("services: [{name: api}, {name: worker}]"), &names)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "worker"}, names)
+
+		var reading struct {
+			T int     `json:"t"`
+			V float64 `json:"v"`
+		}
+		err = MustCompile("$.readings[0]", WithDecoder(CBORDecoder)).EvaluateInto(mustHex(t, TelemetryCbor), &reading)
+		require.NoError(t, err)
+		assert.Equal(t, 1, reading.T)
+		assert.Equal(t, 20.5, reading.V)
+	})
+}
+
+func TestGet(t *testing.T) {
+	t.Run("single value", func(t *testing.T) {
+		price, err := Get[float64](MustCompile("$.store.bicycle.price"), []byte(StoreJson))
+		require.NoError(t, err)
+		assert.Equal(t, 19.95, price)
+	})
+
+	t.Run("array as slice", func(t *testing.T) {
+		books, err := Get[[]testBook](MustCompile("$.store.book"), []byte(StoreJson))
+		require.NoError(t, err)
+		assert.Len(t, books, 4)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, err := Get[string](MustCompile("$.missing"), []byte(StoreJson))
+		assert.True(t, errors.Is(err, ErrNoMatch))
+	})
+
+	t.Run("multiple matches", func(t *testing.T) {
+		_, err := Get[string](MustCompile("$..author"), []byte(StoreJson))
+		assert.True(t, errors.Is(err, ErrMultipleMatches))
+		assert.EqualError(t, err, "found 4 matches: jsonpath matched more than one value")
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		_, err := Get[int](MustCompile("$.store.bicycle.color"), []byte(StoreJson))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to decode match into *int")
+	})
+
+	t.Run("decoder", func(t *testing.T) {
+		age, err := Get[int](MustCompile("$.users[0].age", WithDecoder(MessagePackDecoder)), mustHex(t, UsersMsgpack))
+		require.NoError(t, err)
+		assert.Equal(t, 30, age)
+
+		id, err := Get[uint64](MustCompile("$.id", WithDecoder(YAMLDecoder), WithNumberMode(JSONNumbers)), []byte("id: 12345678901234567890"))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(12345678901234567890), id)
+
+		_, err = Get[string](MustCompile("$.missing", WithDecoder(YAMLDecoder)), []byte("a: 1"))
+		assert.True(t, errors.Is(err, ErrNoMatch))
+	})
+}
+
+func TestAll(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		books, err := All[testBook](MustCompile("$.store.book[?@.price < 10]"), []byte(StoreJson))
+		require.NoError(t, err)
+		require.Len(t, books, 2)
+		assert.Equal(t, "Nigel Rees", books[0].Author)
+		assert.Equal(t, "Herman Melville", books[1].Author)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		values, err := All[string](MustCompile("$.missing"), []byte(StoreJson))
+		require.NoError(t, err)
+		assert.NotNil(t, values)
+		assert.Empty(t, values)
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		values, err := All[string](MustCompile("$.test"), []byte(`{"test:true}`))
+		assert.Error(t, err)
+		assert.Nil(t, values)
+	})
+
+	t.Run("decoder", func(t *testing.T) {
+		replicas, err := All[int](MustCompile("$.services[*].replicas", WithDecoder(YAMLDecoder)), []byte("services: [{replicas: 2}, {replicas: 4}]"))
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 4}, replicas)
+
+		values, err := All[string](MustCompile("$.test", WithDecoder(YAMLDecoder)), []byte("test: [1, 2"))
+		assert.Error(t, err)
+		assert.Nil(t, values)
+	})
+}
@@ -0,0 +1,225 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Decoder parses a document into the values that a jsonpath is evaluated
+// against. Objects must be decoded as a map[string]interface{}, arrays as a
+// []interface{}, and numbers in the provided NumberMode: as a float64, a
+// json.Number or as a *big.Int or *big.Float. Any other value is compared as
+// is.
+type Decoder interface {
+	Decode(data []byte, numbers NumberMode) (interface{}, error)
+}
+
+// JSONDecoder is the Decoder for json documents, it is used when an Evaluator
+// is not given a different Decoder.
+var JSONDecoder Decoder = jsonDecoder{}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Decode(data []byte, numbers NumberMode) (interface{}, error) {
+	return decodeJson(data, numbers)
+}
+
+// WithDecoder will change how the Evaluator parses the documents that it is
+// given, so that the same jsonpath can be evaluated against other formats
+// like YAML. Raw results can only be returned for json documents, but results
+// can still be decoded into Go values with Get, All and EvaluateInto.
+func WithDecoder(decoder Decoder) Option {
+	return func(e *Evaluator) {
+		if _, ok := decoder.(jsonDecoder); ok {
+			decoder = nil
+		}
+
+		e.decoder = decoder
+	}
+}
+
+// checkJSONInput will return an error if the Evaluator does not decode json,
+// for features that work on the json itself such as raw results.
+func (e *Evaluator) checkJSONInput(feature string) error {
+	if e.decoder != nil {
+		return errors.Errorf("%s are only supported for json documents", feature)
+	}
+
+	return nil
+}
+
+// rawMatches returns the raw json of each result like EvaluateRaw. Documents
+// that are not json do not contain the raw json of their results, so the
+// results are encoded as json instead.
+func (e *Evaluator) rawMatches(data []byte) ([]json.RawMessage, error) {
+	if e.decoder == nil {
+		return e.EvaluateRaw(data)
+	}
+
+	values, err := e.Evaluate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeRaw(values)
+}
+
+// rawMatches returns the raw json of the results of each query, like
+// Evaluator.rawMatches.
+func (q *QuerySet) rawMatches(data []byte) (map[string][]json.RawMessage, error) {
+	if q.settings.decoder == nil {
+		return q.EvaluateRaw(data)
+	}
+
+	values, err := q.Evaluate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]json.RawMessage, len(values))
+	for name, matches := range values {
+		if results[name], err = encodeRaw(matches); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// encodeRaw will encode values that are not in the json, like the results of
+// a function, as json. A *big.Float is written as a number instead of as a
+// string.
+func encodeRaw(values []interface{}) ([]json.RawMessage, error) {
+	raw := make([]json.RawMessage, len(values))
+	for i, value := range values {
+		if float, ok := value.(*big.Float); ok && !float.IsInf() {
+			raw[i] = json.RawMessage(float.Text('g', -1))
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode result")
+		}
+
+		raw[i] = encoded
+	}
+
+	return raw, nil
+}
+
+// maxBinaryDepth is how deeply arrays and maps can be nested in a binary
+// document, the same as the limit of encoding/json.
+const maxBinaryDepth = 10000
+
+// binaryReader reads the items of a binary document such as CBOR or
+// MessagePack.
+type binaryReader struct {
+	data   []byte
+	offset int
+	depth  int
+}
+
+// readByte will read the next byte of the document.
+func (r *binaryReader) readByte() (byte, error) {
+	if r.offset >= len(r.data) {
+		return 0, errors.Errorf("unexpected end of input at offset %d", r.offset)
+	}
+
+	b := r.data[r.offset]
+	r.offset++
+
+	return b, nil
+}
+
+// read will read the next n bytes of the document. The bytes are copied so
+// that results do not share memory with the document.
+func (r *binaryReader) read(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)-r.offset) {
+		return nil, errors.Errorf("unexpected end of input at offset %d", r.offset)
+	}
+
+	data := make([]byte, n)
+	copy(data, r.data[r.offset:])
+	r.offset += int(n)
+
+	return data, nil
+}
+
+// readUint will read a big endian unsigned integer of size bytes.
+func (r *binaryReader) readUint(size int) (uint64, error) {
+	if size > len(r.data)-r.offset {
+		return 0, errors.Errorf("unexpected end of input at offset %d", r.offset)
+	}
+
+	value := bigEndian(r.data[r.offset : r.offset+size])
+	r.offset += size
+
+	return value, nil
+}
+
+// capacity returns how many items to allocate for a collection of length
+// items. Every item is at least a byte, so a length that is longer than the
+// rest of the document is not trusted.
+func (r *binaryReader) capacity(length uint64) int {
+	if remaining := uint64(len(r.data) - r.offset); length > remaining {
+		return int(remaining)
+	}
+
+	return int(length)
+}
+
+// enter is called before the items of an array or map are read, it returns an
+// error if they are nested too deeply. leave must be called afterwards.
+func (r *binaryReader) enter() error {
+	r.depth++
+	if r.depth > maxBinaryDepth {
+		return errors.Errorf("exceeded max depth of %d at offset %d", maxBinaryDepth, r.offset)
+	}
+
+	return nil
+}
+
+func (r *binaryReader) leave() {
+	r.depth--
+}
+
+// finish returns an error if there is anything after the first item of the
+// document.
+func (r *binaryReader) finish() error {
+	if r.offset < len(r.data) {
+		return errors.Errorf("unexpected data after top-level value at offset %d", r.offset)
+	}
+
+	return nil
+}
+
+// bigEndian returns the value of the big endian unsigned integer.
+func bigEndian(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+
+	return value
+}
+
+// binaryKey returns the key of a map in a binary document as a string. Strings
+// and byte strings are used as is, and numbers and booleans are formatted.
+func binaryKey(key jsonNode) (string, error) {
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case []byte:
+		return string(k), nil
+	case json.Number:
+		return string(k), nil
+	case bool:
+		return strconv.FormatBool(k), nil
+	default:
+		return "", errors.Errorf("unsupported map key of type %T", key)
+	}
+}
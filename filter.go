@@ -0,0 +1,192 @@
+package jsonpath
+
+type (
+	// filterAction selects the children of each node that the filter
+	// expression is true for.
+	filterAction struct {
+		expression filterExpression
+	}
+
+	filterExpression interface {
+		Test(ctx *evalContext, node jsonNode) (bool, error)
+	}
+
+	// filterValue is one side of a comparison. If the value does not exist,
+	// such as a query that did not select anything, then ok will be false.
+	filterValue interface {
+		Value(ctx *evalContext, node jsonNode) (value jsonNode, ok bool, err error)
+	}
+
+	orFilter         []filterExpression
+	andFilter        []filterExpression
+	notFilter        struct{ expression filterExpression }
+	comparisonFilter struct {
+		left, right filterValue
+		operator    comparisonToken
+	}
+
+	// queryFilter is a query within a filter expression. When it is used on
+	// its own it tests whether the query selects anything, when it is used in
+	// a comparison it must be singular.
+	queryFilter struct {
+		actions []jsonAction
+	}
+
+	literalFilter struct {
+		value jsonNode
+	}
+)
+
+var (
+	_ filterExpression = orFilter{}
+	_ filterExpression = andFilter{}
+	_ filterExpression = notFilter{}
+	_ filterExpression = comparisonFilter{}
+	_ filterExpression = queryFilter{}
+
+	_ filterValue = queryFilter{}
+	_ filterValue = literalFilter{}
+)
+
+func (f filterAction) Execute(ctx *evalContext) (jsonNode, error) {
+	nodes, _ := nodesOf(ctx.data)
+	items := make(nodeList, 0)
+	for _, node := range nodes {
+		for _, child := range appendChildren(nil, node) {
+			if err := ctx.cancel.check(); err != nil {
+				return nil, err
+			}
+
+			ok, err := f.expression.Test(ctx, child.value)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				items = append(items, child)
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func (o orFilter) Test(ctx *evalContext, node jsonNode) (bool, error) {
+	for _, expression := range o {
+		ok, err := expression.Test(ctx, node)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func (a andFilter) Test(ctx *evalContext, node jsonNode) (bool, error) {
+	for _, expression := range a {
+		ok, err := expression.Test(ctx, node)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (n notFilter) Test(ctx *evalContext, node jsonNode) (bool, error) {
+	ok, err := n.expression.Test(ctx, node)
+	return !ok, err
+}
+
+func (c comparisonFilter) Test(ctx *evalContext, node jsonNode) (bool, error) {
+	left, leftOk, err := c.left.Value(ctx, node)
+	if err != nil {
+		return false, err
+	}
+
+	right, rightOk, err := c.right.Value(ctx, node)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.operator {
+	case equals:
+		return compareEqual(left, leftOk, right, rightOk), nil
+	case notEquals:
+		return !compareEqual(left, leftOk, right, rightOk), nil
+	case lessThan:
+		return leftOk && rightOk && compareLess(left, right), nil
+	case lessThanOrEqualTo:
+		return leftOk && rightOk && compareLess(left, right) ||
+			compareEqual(left, leftOk, right, rightOk), nil
+	case greaterThan:
+		return leftOk && rightOk && compareLess(right, left), nil
+	case greaterThanOrEqualTo:
+		return leftOk && rightOk && compareLess(right, left) ||
+			compareEqual(left, leftOk, right, rightOk), nil
+	default:
+		return false, nil
+	}
+}
+
+func (q queryFilter) Test(ctx *evalContext, node jsonNode) (bool, error) {
+	nodes, err := q.evaluate(ctx, node)
+	if err != nil {
+		return false, err
+	}
+
+	return len(nodes) > 0, nil
+}
+
+func (q queryFilter) Value(ctx *evalContext, node jsonNode) (jsonNode, bool, error) {
+	nodes, err := q.evaluate(ctx, node)
+	if err != nil || len(nodes) == 0 {
+		return nil, false, err
+	}
+
+	return scalarOf(nodes[0].value), true, nil
+}
+
+// evaluate will run the query with the provided node as the current node.
+// Absolute queries start with a rootAccessAction which will move back up to
+// the root of the document.
+func (q queryFilter) evaluate(ctx *evalContext, node jsonNode) ([]locatedNode, error) {
+	// The location of the node within the filter does not matter, so it is
+	// not tracked.
+	nodeCtx := ctx.child(nodeList{{value: node}})
+
+	result, err := runActions(nodeCtx, q.actions)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, _ := nodesOf(result)
+
+	return nodes, nil
+}
+
+func (l literalFilter) Value(ctx *evalContext, node jsonNode) (jsonNode, bool, error) {
+	return l.value, true, nil
+}
+
+// compareEqual will return true if both values exist and are equal, or if
+// neither of the values exist.
+func compareEqual(left jsonNode, leftOk bool, right jsonNode, rightOk bool) bool {
+	if !leftOk || !rightOk {
+		return leftOk == rightOk
+	}
+
+	return valuesEqual(left, right)
+}
+
+// compareLess will return true if left is less than right. Only numbers and
+// strings can be ordered, any other values are never less than each other.
+func compareLess(left, right jsonNode) bool {
+	if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		return ok && l < r
+	}
+
+	comparison, ok := compareNumbers(left, right)
+	return ok && comparison < 0
+}
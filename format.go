@@ -0,0 +1,308 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Notation determines how names are written when a path is formatted.
+type Notation uint8
+
+const (
+	// BracketNotation writes every name within brackets, for example
+	// `$['store']['book'][0]`. This is the notation used for normalized paths.
+	BracketNotation Notation = iota
+
+	// DotNotation writes names with a period wherever the name allows it,
+	// for example `$.store.book[0]`. This produces the shortest form of a
+	// path.
+	DotNotation
+)
+
+// pathFormatter writes the parts of a path as a string. When normalize is
+// true any parts of the path that are written explicitly but have the same
+// meaning as their default are omitted, such as a slice with a step of 1.
+type pathFormatter struct {
+	notation  Notation
+	normalize bool
+}
+
+// canonicalFormatter is used by the String methods of the Path and its parts.
+// It does not normalize so that the path can be parsed back into an identical
+// Path.
+var canonicalFormatter = pathFormatter{
+	notation: BracketNotation,
+}
+
+// Format will parse the provided jsonpath and write it in a canonical form
+// using the provided notation. Paths that are written differently but have
+// the same meaning, such as `$.a['b']` and `$["a"].b`, will be formatted the
+// same way. An error is returned if the jsonpath is not valid.
+func Format(path string, notation Notation) (string, error) {
+	parsed, err := Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.Format(notation), nil
+}
+
+// Canonicalize will parse the provided jsonpath and write it as a normalized
+// path using bracket notation. The result can be used to compare or dedupe
+// jsonpaths. An error is returned if the jsonpath is not valid.
+func Canonicalize(path string) (string, error) {
+	return Format(path, BracketNotation)
+}
+
+// Format will write the path in a canonical form using the provided notation.
+func (p *Path) Format(notation Notation) string {
+	formatter := pathFormatter{
+		notation:  notation,
+		normalize: true,
+	}
+
+	return formatter.path(p)
+}
+
+func (f pathFormatter) path(path *Path) string {
+	if path.Function != "" {
+		return "$" + f.segments(path.Segments) + "." + string(path.Function) + "()"
+	}
+
+	return "$" + f.segments(path.Segments)
+}
+
+func (f pathFormatter) segments(segments []Segment) string {
+	var builder strings.Builder
+	for _, segment := range segments {
+		builder.WriteString(f.segment(segment))
+	}
+
+	return builder.String()
+}
+
+func (f pathFormatter) segment(segment Segment) string {
+	prefix := ""
+	if _, ok := segment.(DescendantSegment); ok {
+		prefix = ".."
+	}
+
+	selectors := segment.Selectors()
+	if f.notation == DotNotation && len(selectors) == 1 {
+		switch selector := selectors[0].(type) {
+		case WildcardSelector:
+			if prefix == "" {
+				return ".*"
+			}
+
+			return prefix + "*"
+		case NameSelector:
+			if isDotName(string(selector)) {
+				if prefix == "" {
+					return "." + string(selector)
+				}
+
+				return prefix + string(selector)
+			}
+		}
+	}
+
+	parts := make([]string, len(selectors))
+	for i, selector := range selectors {
+		parts[i] = f.selector(selector)
+	}
+
+	return prefix + "[" + strings.Join(parts, ",") + "]"
+}
+
+func (f pathFormatter) selector(selector Selector) string {
+	switch s := selector.(type) {
+	case NameSelector:
+		return quoteString(string(s))
+	case IndexSelector:
+		return strconv.Itoa(int(s))
+	case SliceSelector:
+		return f.slice(s)
+	case WildcardSelector:
+		return "*"
+	case FilterSelector:
+		return "?" + f.expression(s.Expression)
+	default:
+		return fmt.Sprint(selector)
+	}
+}
+
+func (f pathFormatter) slice(slice SliceSelector) string {
+	start, end, step := slice.Start, slice.End, slice.Step
+	if f.normalize && (step == nil || *step == 1) {
+		// A step of 1 is the default, and so is a start of 0 when stepping
+		// forward.
+		step = nil
+		if start != nil && *start == 0 {
+			start = nil
+		}
+	}
+
+	var builder strings.Builder
+	if start != nil {
+		builder.WriteString(strconv.Itoa(*start))
+	}
+	builder.WriteByte(':')
+	if end != nil {
+		builder.WriteString(strconv.Itoa(*end))
+	}
+	if step != nil {
+		builder.WriteByte(':')
+		builder.WriteString(strconv.Itoa(*step))
+	}
+
+	return builder.String()
+}
+
+const (
+	precedenceOr = iota
+	precedenceAnd
+	precedenceNot
+)
+
+func (f pathFormatter) expression(expression Expression) string {
+	switch e := expression.(type) {
+	case OrExpression:
+		parts := make([]string, len(e))
+		for i, inner := range e {
+			parts[i] = f.innerExpression(inner, precedenceOr)
+		}
+
+		return strings.Join(parts, " || ")
+	case AndExpression:
+		parts := make([]string, len(e))
+		for i, inner := range e {
+			parts[i] = f.innerExpression(inner, precedenceAnd)
+		}
+
+		return strings.Join(parts, " && ")
+	case NotExpression:
+		return "!" + f.innerExpression(e.Expression, precedenceNot)
+	case ComparisonExpression:
+		return fmt.Sprintf("%s %s %s", f.expression(e.Left), e.Operator, f.expression(e.Right))
+	case QueryExpression:
+		if e.Relative {
+			return "@" + f.segments(e.Segments)
+		}
+
+		return "$" + f.segments(e.Segments)
+	case LiteralExpression:
+		return formatLiteral(e.Value)
+	default:
+		return fmt.Sprint(expression)
+	}
+}
+
+// innerExpression will wrap the expression in parentheses if its operator
+// binds less tightly than the operator of the expression that contains it.
+func (f pathFormatter) innerExpression(expression Expression, parent int) string {
+	precedence := precedenceNot
+	switch expression.(type) {
+	case OrExpression:
+		precedence = precedenceOr
+	case AndExpression:
+		precedence = precedenceAnd
+	case ComparisonExpression:
+		// A comparison cannot be negated without parentheses.
+		if parent == precedenceNot {
+			precedence = precedenceAnd
+		}
+	}
+
+	if precedence < parent {
+		return "(" + f.expression(expression) + ")"
+	}
+
+	return f.expression(expression)
+}
+
+func formatLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return quoteString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case json.Number:
+		return string(v)
+	case float64:
+		str := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(str, ".") {
+			// Keep the decimal point so the literal is parsed as a decimal
+			// again rather than an integer.
+			str += ".0"
+		}
+
+		return str
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// quoteString will wrap the string in single quotes. Any characters that
+// cannot appear within a quoted string are escaped, so any string can be
+// safely used as a field name.
+func quoteString(str string) string {
+	var builder strings.Builder
+	builder.Grow(len(str) + 2)
+	builder.WriteByte('\'')
+	for _, character := range str {
+		switch character {
+		case '\'', '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(character)
+		case '\b':
+			builder.WriteString(`\b`)
+		case '\f':
+			builder.WriteString(`\f`)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		default:
+			if character < 0x20 {
+				// Any other control characters must be escaped as well.
+				fmt.Fprintf(&builder, `\u%04x`, character)
+				continue
+			}
+
+			builder.WriteRune(character)
+		}
+	}
+	builder.WriteByte('\'')
+
+	return builder.String()
+}
+
+// isDotName returns true if the name can be written after a period without
+// quotes. The name must be tokenized as a single string token.
+func isDotName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	tokenizer := newPathTokenizer(name)
+	if !tokenizer.isStringPart(name[0]) {
+		return false
+	}
+
+	for i := 1; i < len(name); i++ {
+		if !tokenizer.isStringContinuation(name[i]) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,98 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	t.Run("equivalent paths", func(t *testing.T) {
+		paths := []string{
+			"$.a['b']",
+			`$["a"].b`,
+			"$.a.b",
+			"a.b",
+			"$ .a [ 'b' ]",
+		}
+
+		for _, path := range paths {
+			bracket, err := Format(path, BracketNotation)
+			require.NoError(t, err, path)
+			assert.Equal(t, "$['a']['b']", bracket, path)
+
+			dot, err := Format(path, DotNotation)
+			require.NoError(t, err, path)
+			assert.Equal(t, "$.a.b", dot, path)
+		}
+	})
+
+	t.Run("dot notation", func(t *testing.T) {
+		paths := map[string]string{
+			"$['store']['book'][*]['author']": "$.store.book.*.author",
+			"$..['author']":                   "$..author",
+			"$..[*]":                          "$..*",
+			"$['a b']['c.d']['it\\'s']":       "$['a b']['c.d']['it\\'s']",
+			"$['a1']['_b']['1a']['']":         "$.a1._b['1a']['']",
+			"$['null']['true']":               "$.null.true",
+			"$['名前']":                         "$.名前",
+			"$['a','b'][0][1:2]":              "$['a','b'][0][1:2]",
+			"$[?(@['price'] < $['max'])]":     "$[?@.price < $.max]",
+			"$[?@['a'] && !(@['b'] == 'x')]":  "$[?@.a && !(@.b == 'x')]",
+		}
+
+		for input, expected := range paths {
+			formatted, err := Format(input, DotNotation)
+			require.NoError(t, err, input)
+			assert.Equal(t, expected, formatted, input)
+
+			// The formatted path must mean the same thing as the input.
+			original, err := Canonicalize(input)
+			require.NoError(t, err, input)
+			reformatted, err := Canonicalize(formatted)
+			require.NoError(t, err, formatted)
+			assert.Equal(t, original, reformatted, input)
+		}
+	})
+
+	t.Run("normalized slices", func(t *testing.T) {
+		paths := map[string]string{
+			"$[0:5:1]": "$[:5]",
+			"$[0:5]":   "$[:5]",
+			"$[1:5:1]": "$[1:5]",
+			"$[::1]":   "$[:]",
+			"$[0::2]":  "$[0::2]",
+			"$[::-1]":  "$[::-1]",
+		}
+
+		for input, expected := range paths {
+			formatted, err := Canonicalize(input)
+			require.NoError(t, err, input)
+			assert.Equal(t, expected, formatted, input)
+		}
+	})
+
+	t.Run("idempotent", func(t *testing.T) {
+		paths := []string{
+			"$[?(@.a && @.b) && @.c]",
+			"$..book[?(@.price < 10 || !@.isbn)].title",
+			`$["quote\"d"]..*[-1:]`,
+		}
+
+		for _, path := range paths {
+			for _, notation := range []Notation{BracketNotation, DotNotation} {
+				once, err := Format(path, notation)
+				require.NoError(t, err, path)
+				twice, err := Format(once, notation)
+				require.NoError(t, err, once)
+				assert.Equal(t, once, twice, path)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Canonicalize("$[")
+		assert.Error(t, err)
+	})
+}
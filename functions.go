@@ -0,0 +1,417 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Function is a function that can be written at the end of a jsonpath to
+// aggregate all of its results into a single value, for example
+// `$.items[*].price.sum()`. When the jsonpath is singular and selects an
+// array, like `$.prices.sum()`, the function is applied to the elements of
+// the array instead.
+type Function string
+
+const (
+	// SumFunction adds up the numbers, it is 0 if there are none.
+	SumFunction Function = "sum"
+
+	// AvgFunction is the mean of the numbers, it has no result if there are
+	// none.
+	AvgFunction Function = "avg"
+
+	// MinFunction is the smallest of the numbers, it has no result if there
+	// are none.
+	MinFunction Function = "min"
+
+	// MaxFunction is the largest of the numbers, it has no result if there
+	// are none.
+	MaxFunction Function = "max"
+
+	// LengthFunction is the number of values. When the jsonpath is singular
+	// and selects an object or a string then it is the number of members of
+	// the object or characters of the string.
+	LengthFunction Function = "length"
+
+	// DistinctFunction is an array of the values without any duplicates, in
+	// the order that they were first found.
+	DistinctFunction Function = "distinct"
+
+	// FirstFunction is the first value, it has no result if there are none.
+	FirstFunction Function = "first"
+
+	// LastFunction is the last value, it has no result if there are none.
+	LastFunction Function = "last"
+
+	// KeysFunction is an array of the names of the members of each object,
+	// in order of the objects and then the names.
+	KeysFunction Function = "keys"
+
+	// ConcatFunction joins the strings, numbers and booleans into a single
+	// string.
+	ConcatFunction Function = "concat"
+)
+
+// aggregator computes the result of a function for its values. If the
+// function does not have a result then ok is false. Numbers in the result are
+// in the provided mode.
+type aggregator func(values []interface{}, numbers NumberMode) (result interface{}, ok bool)
+
+var aggregators = map[Function]aggregator{
+	SumFunction:      aggregateSum,
+	AvgFunction:      aggregateAvg,
+	MinFunction:      aggregateMin,
+	MaxFunction:      aggregateMax,
+	LengthFunction:   aggregateLength,
+	DistinctFunction: aggregateDistinct,
+	FirstFunction:    aggregateFirst,
+	LastFunction:     aggregateLast,
+	KeysFunction:     aggregateKeys,
+	ConcatFunction:   aggregateConcat,
+}
+
+// compiledFunction is the function at the end of a compiled jsonpath.
+type compiledFunction struct {
+	function Function
+
+	// singular is true if the jsonpath without the function is singular, in
+	// which case an array result is replaced by its elements.
+	singular bool
+}
+
+// Aggregate will apply the function to the results of a jsonpath, such as the
+// results of Evaluate, the same as if the function was written at the end of
+// the jsonpath. Numbers in the result are decoded in the same way as the
+// numbers in the values. ErrNoMatch is returned if the function has no
+// result, like the first of no values.
+func Aggregate(function Function, values []interface{}) (interface{}, error) {
+	aggregate, ok := aggregators[function]
+	if !ok {
+		return nil, errors.Errorf("unknown function %s()", function)
+	}
+
+	result, ok := aggregate(values, numberModeOf(values))
+	if !ok {
+		return nil, ErrNoMatch
+	}
+
+	return result, nil
+}
+
+// Aggregate will run the compiled jsonpath against the provided json and
+// apply the function to its results. See the package level Aggregate.
+func (e *Evaluator) Aggregate(data []byte, function Function) (interface{}, error) {
+	values, err := e.Evaluate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return Aggregate(function, values)
+}
+
+// isFunction returns true if the name is the name of a function.
+func isFunction(name string) bool {
+	_, ok := aggregators[Function(name)]
+	return ok
+}
+
+// apply will return the nodes with the result of the function, the result
+// does not have a location.
+func (f *compiledFunction) apply(nodes []locatedNode, numbers NumberMode) []locatedNode {
+	values := valuesOf(nodes)
+	if f.singular && len(values) == 1 {
+		if length, ok := arrayLength(values[0]); ok {
+			elements := make([]interface{}, length)
+			for i := range elements {
+				elements[i] = elementAt(values[0], i)
+			}
+
+			values = elements
+		} else if f.function == LengthFunction {
+			// The length of a single object or string is its own length.
+			switch value := scalarOf(values[0]).(type) {
+			case string:
+				return []locatedNode{{value: int64Node(int64(utf8.RuneCountInString(value)), numbers)}}
+			default:
+				if keys, ok := objectKeys(value); ok {
+					return []locatedNode{{value: int64Node(int64(len(keys)), numbers)}}
+				}
+			}
+		}
+	}
+
+	result, ok := aggregators[f.function](values, numbers)
+	if !ok {
+		return []locatedNode{}
+	}
+
+	return []locatedNode{{value: result}}
+}
+
+// numberModeOf returns the NumberMode that the numbers in the values were
+// decoded with.
+func numberModeOf(values []interface{}) NumberMode {
+	for _, value := range values {
+		switch scalarOf(value).(type) {
+		case json.Number:
+			return JSONNumbers
+		case *big.Int, *big.Float:
+			return BigNumbers
+		}
+	}
+
+	return Float64Numbers
+}
+
+// numbersOf returns the values that are numbers, anything else is ignored.
+func numbersOf(values []interface{}) []jsonNode {
+	numbers := make([]jsonNode, 0, len(values))
+	for _, value := range values {
+		value = scalarOf(value)
+		if _, ok := compareNumbers(value, value); ok {
+			numbers = append(numbers, value)
+		}
+	}
+
+	return numbers
+}
+
+// sumOf adds up the numbers. If any of them is a float64 then they are added
+// as float64s and exact is nil, otherwise they are added exactly.
+func sumOf(numbers []jsonNode) (exact *big.Rat, float float64) {
+	exact = new(big.Rat)
+	inexact := false
+	for _, number := range numbers {
+		value, ok := ratOf(number)
+		if !ok {
+			// Only float64s and infinite big.Floats cannot be exact.
+			inexact = true
+			value, _ := float64Of(number)
+			float += value
+			continue
+		}
+
+		exact.Add(exact, value)
+	}
+
+	if inexact {
+		sum, _ := exact.Float64()
+		return nil, float + sum
+	}
+
+	return exact, 0
+}
+
+func aggregateSum(values []interface{}, numbers NumberMode) (interface{}, bool) {
+	exact, float := sumOf(numbersOf(values))
+	if exact == nil {
+		return float, true
+	}
+
+	return ratNode(exact, numbers), true
+}
+
+func aggregateAvg(values []interface{}, numbers NumberMode) (interface{}, bool) {
+	items := numbersOf(values)
+	if len(items) == 0 {
+		return nil, false
+	}
+
+	exact, float := sumOf(items)
+	if exact == nil {
+		return float / float64(len(items)), true
+	}
+
+	return ratNode(exact.Quo(exact, new(big.Rat).SetInt64(int64(len(items)))), numbers), true
+}
+
+func aggregateMin(values []interface{}, _ NumberMode) (interface{}, bool) {
+	return extremeOf(numbersOf(values), -1)
+}
+
+func aggregateMax(values []interface{}, _ NumberMode) (interface{}, bool) {
+	return extremeOf(numbersOf(values), 1)
+}
+
+// extremeOf returns the smallest number if direction is -1 or the largest
+// number if it is 1. The number is returned as it is.
+func extremeOf(numbers []jsonNode, direction int) (interface{}, bool) {
+	if len(numbers) == 0 {
+		return nil, false
+	}
+
+	extreme := numbers[0]
+	for _, number := range numbers[1:] {
+		if comparison, _ := compareNumbers(number, extreme); comparison == direction {
+			extreme = number
+		}
+	}
+
+	return extreme, true
+}
+
+func aggregateLength(values []interface{}, numbers NumberMode) (interface{}, bool) {
+	return int64Node(int64(len(values)), numbers), true
+}
+
+func aggregateDistinct(values []interface{}, _ NumberMode) (interface{}, bool) {
+	distinct := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		found := false
+		for _, existing := range distinct {
+			if valuesEqual(value, existing) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			distinct = append(distinct, value)
+		}
+	}
+
+	return distinct, true
+}
+
+func aggregateFirst(values []interface{}, _ NumberMode) (interface{}, bool) {
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	return values[0], true
+}
+
+func aggregateLast(values []interface{}, _ NumberMode) (interface{}, bool) {
+	if len(values) == 0 {
+		return nil, false
+	}
+
+	return values[len(values)-1], true
+}
+
+func aggregateKeys(values []interface{}, _ NumberMode) (interface{}, bool) {
+	keys := make([]interface{}, 0)
+	for _, value := range values {
+		names, _ := objectKeys(value)
+		for _, name := range names {
+			keys = append(keys, name)
+		}
+	}
+
+	return keys, true
+}
+
+func aggregateConcat(values []interface{}, _ NumberMode) (interface{}, bool) {
+	var builder strings.Builder
+	for _, value := range values {
+		switch v := scalarOf(value).(type) {
+		case string:
+			builder.WriteString(v)
+		case bool:
+			builder.WriteString(strconv.FormatBool(v))
+		default:
+			if text, ok := numberText(v); ok {
+				builder.WriteString(text)
+			}
+		}
+	}
+
+	return builder.String(), true
+}
+
+// ratOf returns the exact value of a number that is not a float64.
+func ratOf(number jsonNode) (*big.Rat, bool) {
+	switch n := number.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case json.Number:
+		return new(big.Rat).SetString(string(n))
+	case *big.Int:
+		return new(big.Rat).SetInt(n), true
+	case *big.Float:
+		if n.IsInf() {
+			return nil, false
+		}
+
+		value, _ := n.Rat(nil)
+		return value, true
+	default:
+		return nil, false
+	}
+}
+
+// ratNode returns the exact number in the provided mode. Integers stay
+// integers, and a json.Number is written exactly when it has a finite
+// decimal.
+func ratNode(value *big.Rat, numbers NumberMode) jsonNode {
+	if value.IsInt() {
+		return bigIntNode(new(big.Int).Set(value.Num()), numbers)
+	}
+
+	switch numbers {
+	case JSONNumbers:
+		if digits, ok := decimalDigits(value); ok {
+			return json.Number(value.FloatString(digits))
+		}
+
+		float, _ := value.Float64()
+		return json.Number(strconv.FormatFloat(float, 'g', -1, 64))
+	case BigNumbers:
+		return new(big.Float).SetPrec(bigFloatPrecision).SetRat(value)
+	default:
+		float, _ := value.Float64()
+		return float
+	}
+}
+
+// decimalDigits returns how many digits after the decimal point are needed to
+// write the number exactly, or false if it cannot be written exactly.
+func decimalDigits(value *big.Rat) (int, bool) {
+	denominator := new(big.Int).Set(value.Denom())
+	remainder := new(big.Int)
+	counts := make([]int, 2)
+	for i, factor := range []*big.Int{big.NewInt(2), big.NewInt(5)} {
+		for {
+			quotient, mod := new(big.Int).QuoRem(denominator, factor, remainder)
+			if mod.Sign() != 0 {
+				break
+			}
+
+			denominator = quotient
+			counts[i]++
+		}
+	}
+
+	if denominator.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+
+	if counts[0] > counts[1] {
+		return counts[0], true
+	}
+
+	return counts[1], true
+}
+
+// numberText returns the number as it would be written in json.
+func numberText(number jsonNode) (string, bool) {
+	switch n := number.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(n, 10), true
+	case json.Number:
+		return string(n), true
+	case *big.Int:
+		return n.String(), true
+	case *big.Float:
+		return n.Text('g', -1), true
+	default:
+		return "", false
+	}
+}
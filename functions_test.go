@@ -0,0 +1,246 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const PurchasesJson = `{
+	"orders": [
+		{"id": "a", "quantity": 2, "price": 1.5, "tags": ["new", "gift"]},
+		{"id": "b", "quantity": 3, "price": 2.25, "tags": ["gift"]},
+		{"id": "c", "quantity": 5, "price": 0.1, "note": "late"}
+	],
+	"totals": [10, 20, 30, 45],
+	"customer": {"name": "Zoë", "email": "zoe@example.com"}
+}`
+
+func TestFunctions(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []interface{}
+	}{
+		{path: "$.orders[*].quantity.sum()", expected: []interface{}{json.Number("10")}},
+		{path: "$.orders[*].price.sum()", expected: []interface{}{json.Number("3.85")}},
+		{path: "$.totals.sum()", expected: []interface{}{json.Number("105")}},
+		{path: "$.orders[*].quantity.avg()", expected: []interface{}{json.Number("3.3333333333333335")}},
+		{path: "$.totals.avg()", expected: []interface{}{json.Number("26.25")}},
+		{path: "$.orders[*].price.min()", expected: []interface{}{json.Number("0.1")}},
+		{path: "$.totals.max()", expected: []interface{}{json.Number("45")}},
+		{path: "$.orders.length()", expected: []interface{}{json.Number("3")}},
+		{path: "$.orders[*].note.length()", expected: []interface{}{json.Number("1")}},
+		{path: "$.customer.length()", expected: []interface{}{json.Number("2")}},
+		{path: "$.customer.name.length()", expected: []interface{}{json.Number("3")}},
+		{path: "$.orders[*].tags[*].distinct()", expected: []interface{}{[]interface{}{"new", "gift"}}},
+		{path: "$.orders[*].id.first()", expected: []interface{}{"a"}},
+		{path: "$.totals.last()", expected: []interface{}{json.Number("45")}},
+		{path: "$.customer.keys()", expected: []interface{}{[]interface{}{"email", "name"}}},
+		{path: "$.orders[*].id.concat()", expected: []interface{}{"abc"}},
+		{path: "$.orders[*].missing.sum()", expected: []interface{}{json.Number("0")}},
+		{path: "$.orders[*].missing.avg()", expected: []interface{}{}},
+		{path: "$.orders[*].missing.first()", expected: []interface{}{}},
+		{path: "$.orders[*].missing.length()", expected: []interface{}{json.Number("0")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			result, err := MustCompile(test.path, WithNumberMode(JSONNumbers)).Evaluate([]byte(PurchasesJson))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+
+	t.Run("float64 numbers", func(t *testing.T) {
+		result, err := MustCompile("$.orders[*].price.sum()").Evaluate([]byte(PurchasesJson))
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.InDelta(t, 3.85, result[0], 1e-9)
+
+		result, err = MustCompile("$.orders.length()").Evaluate([]byte(PurchasesJson))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{3.0}, result)
+	})
+
+	t.Run("big numbers", func(t *testing.T) {
+		result, err := MustCompile("$.totals.sum()", WithNumberMode(BigNumbers)).Evaluate([]byte(PurchasesJson))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{big.NewInt(105)}, result)
+
+		result, err = MustCompile("$.orders[*].price.avg()", WithNumberMode(BigNumbers)).Evaluate([]byte(PurchasesJson))
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.IsType(t, &big.Float{}, result[0])
+		assert.Equal(t, "1.283333333", result[0].(*big.Float).Text('f', 9))
+	})
+
+	t.Run("integers and floats are promoted", func(t *testing.T) {
+		data := []byte(`[1, 2.5, 12345678901234567890]`)
+
+		result, err := MustCompile("$.sum()", WithNumberMode(JSONNumbers)).Evaluate(data)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{json.Number("12345678901234567893.5")}, result)
+
+		result, err = MustCompile("$.max()", WithNumberMode(JSONNumbers)).Evaluate(data)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{json.Number("12345678901234567890")}, result)
+	})
+
+	t.Run("non numbers are ignored", func(t *testing.T) {
+		result, err := MustCompile("$.sum()", WithNumberMode(JSONNumbers)).Evaluate([]byte(`[1, "2", true, null, 3]`))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{json.Number("4")}, result)
+
+		result, err = MustCompile("$.concat()").Evaluate([]byte(`["a", 1, true, null, {"b": 2}]`))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"a1true"}, result)
+	})
+
+	t.Run("first and count", func(t *testing.T) {
+		eval := MustCompile("$.totals[*].max()", WithNumberMode(JSONNumbers))
+
+		first, err := eval.First([]byte(PurchasesJson))
+		require.NoError(t, err)
+		assert.Equal(t, json.Number("45"), first)
+
+		count, err := eval.Count([]byte(PurchasesJson))
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		_, err = MustCompile("$.missing[*].max()").First([]byte(PurchasesJson))
+		assert.True(t, errors.Is(err, ErrNoMatch))
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		for _, mode := range []NumberMode{Float64Numbers, JSONNumbers, BigNumbers} {
+			result, err := MustCompile("$.orders[*].price.sum()", WithNumberMode(mode)).EvaluateRaw([]byte(PurchasesJson))
+			require.NoError(t, err)
+			require.Len(t, result, 1)
+
+			var sum float64
+			require.NoError(t, json.Unmarshal(result[0], &sum))
+			assert.InDelta(t, 3.85, sum, 1e-9)
+		}
+
+		result, err := MustCompile("$.customer.keys()").EvaluateRaw([]byte(PurchasesJson))
+		require.NoError(t, err)
+		assert.Equal(t, []json.RawMessage{json.RawMessage(`["email","name"]`)}, result)
+	})
+
+	t.Run("no locations", func(t *testing.T) {
+		eval := MustCompile("$.totals.sum()")
+
+		err := eval.Walk([]byte(PurchasesJson), func(string, interface{}) bool {
+			return true
+		})
+		assert.EqualError(t, err, "the result of sum() does not have a location")
+
+		_, err = eval.EvaluatePointers([]byte(PurchasesJson))
+		assert.Error(t, err)
+
+		_, err = eval.MergePatch([]byte(PurchasesJson), []byte(`{}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestParse_Functions(t *testing.T) {
+	t.Run("format", func(t *testing.T) {
+		paths := []string{
+			"$['a'][*].sum()",
+			"$.length()",
+			"$..['price'].avg()",
+			"$['a'][?@['b'] > 1].keys()",
+		}
+
+		for _, path := range paths {
+			parsed, err := Parse(path)
+			require.NoError(t, err, path)
+			assert.NotEmpty(t, parsed.Function, path)
+			assert.Equal(t, path, parsed.String(), path)
+		}
+	})
+
+	t.Run("member named like a function", func(t *testing.T) {
+		result, err := Jsonpath([]byte(`{"sum": 1}`), "$.sum")
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{1.0}, result)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		paths := []string{
+			"$..sum()",
+			"$['sum']()",
+			"$.a.foo()",
+			"$.sum().a",
+			"$.sum(",
+			"$.sum(1)",
+			"$.a[0]()",
+			"$.a[?@.b.length() > 1]",
+		}
+
+		for _, path := range paths {
+			_, err := Parse(path)
+			assert.Error(t, err, path)
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		parsed, err := Parse("$.a.length()")
+		require.NoError(t, err)
+
+		_, err = parsed.Pointer()
+		assert.Error(t, err)
+	})
+}
+
+func TestAggregate(t *testing.T) {
+	values, err := MustCompile("$.orders[*].quantity", WithNumberMode(JSONNumbers)).Evaluate([]byte(PurchasesJson))
+	require.NoError(t, err)
+
+	sum, err := Aggregate(SumFunction, values)
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("10"), sum)
+
+	_, err = Aggregate(MinFunction, nil)
+	assert.True(t, errors.Is(err, ErrNoMatch))
+
+	_, err = Aggregate(Function("median"), values)
+	assert.EqualError(t, err, "unknown function median()")
+
+	last, err := MustCompile("$.orders[*].id").Aggregate([]byte(PurchasesJson), LastFunction)
+	require.NoError(t, err)
+	assert.Equal(t, "c", last)
+}
+
+func TestQuerySet_Functions(t *testing.T) {
+	set, err := NewQuerySet(map[string]string{
+		"ids":   "$.orders[*].id",
+		"count": "$.orders.length()",
+		"total": "$.totals.sum()",
+		"avg":   "$.orders[*].missing.avg()",
+	}, WithNumberMode(JSONNumbers))
+	require.NoError(t, err)
+
+	results, err := set.Evaluate([]byte(PurchasesJson))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b", "c"}, results["ids"])
+	assert.Equal(t, []interface{}{json.Number("3")}, results["count"])
+	assert.Equal(t, []interface{}{json.Number("105")}, results["total"])
+	assert.Equal(t, []interface{}{}, results["avg"])
+
+	raw, err := set.EvaluateRaw([]byte(PurchasesJson))
+	require.NoError(t, err)
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`"a"`), json.RawMessage(`"b"`), json.RawMessage(`"c"`)}, raw["ids"])
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`105`)}, raw["total"])
+
+	projected, err := Project([]byte(PurchasesJson), map[string]string{
+		"ids":   "$.orders[*].id",
+		"total": "$.totals.sum()",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"ids":["a","b","c"],"total":105}`, string(projected))
+}
@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package jsonpath
+
+import (
+	"iter"
+)
+
+// Matches will return an iterator over the normalized path and value of each
+// match of the compiled jsonpath in the provided json, like Walk. The
+// iterator evaluates the jsonpath each time it is used and stops as soon as
+// the loop does. Any error from the last iteration is returned by the error
+// function. Matches is only available when building with Go 1.23 or later,
+// use Walk with earlier versions.
+//
+//	matches, errs := eval.Matches(data)
+//	for path, value := range matches {
+//		...
+//	}
+//	if err := errs(); err != nil {
+//		...
+//	}
+func (e *Evaluator) Matches(data []byte) (iter.Seq2[string, interface{}], func() error) {
+	var err error
+	matches := func(yield func(string, interface{}) bool) {
+		err = e.Walk(data, yield)
+	}
+
+	return matches, func() error {
+		return err
+	}
+}
@@ -0,0 +1,48 @@
+//go:build go1.23
+
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_Matches(t *testing.T) {
+	t.Run("range", func(t *testing.T) {
+		matches, errs := MustCompile("$.store.book[1:3].author").Matches([]byte(StoreJson))
+
+		result := map[string]interface{}{}
+		for path, value := range matches {
+			result[path] = value
+		}
+		require.NoError(t, errs())
+		assert.Equal(t, map[string]interface{}{
+			"$['store']['book'][1]['author']": "Evelyn Waugh",
+			"$['store']['book'][2]['author']": "Herman Melville",
+		}, result)
+	})
+
+	t.Run("break", func(t *testing.T) {
+		matches, errs := MustCompile("$..*").Matches([]byte(StoreJson))
+
+		count := 0
+		for range matches {
+			count++
+			if count == 2 {
+				break
+			}
+		}
+		require.NoError(t, errs())
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		matches, errs := MustCompile("$..book").Matches([]byte(`{"book":`))
+		for range matches {
+			t.Fatal("there should not be any matches")
+		}
+		assert.Error(t, errs())
+	})
+}
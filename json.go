@@ -1,7 +1,9 @@
 package jsonpath
 
 import (
+	"bytes"
 	"encoding/json"
+	"sort"
 
 	"github.com/pkg/errors"
 )
@@ -19,34 +21,105 @@ func parseJson(input []byte) (jsonNode, error) {
 	return data, nil
 }
 
+// encodeJson will encode the value like json.Marshal, but without escaping
+// html characters within strings so that values that were not changed are
+// written as they were.
+func encodeJson(value jsonNode) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	// The encoder always ends the json with a newline.
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
 type (
-	jsonNode         interface{}
-	jsonArray        = []interface{}
-	jsonObject       = map[string]interface{}
-	jsonMutatedArray []jsonNode
+	jsonNode   interface{}
+	jsonArray  = []interface{}
+	jsonObject = map[string]interface{}
+
+	// nodeList is the nodes that were selected by a step of an evaluation.
+	nodeList []locatedNode
+
+	// jsonMutatedArray is the nodes that were selected by a recursive decent.
+	jsonMutatedArray []locatedNode
 )
 
+// isArray returns true if the value is an array, or a Node of ArrayKind.
 func isArray(data jsonNode) bool {
-	// We can type check this against an array of interfaces instead of using
-	// reflect. This is probably faster.
-	_, ok := data.(jsonArray)
+	_, ok := arrayLength(data)
 	return ok
 }
 
+// isObject returns true if the value is an object, or a Node of ObjectKind.
 func isObject(data jsonNode) bool {
-	// We can type check this against an array of interfaces instead of using
-	// reflect. This is probably faster.
-	// TODO (elliotcourant) Can integers be keys in json? This assertion would
-	//  fail if they are.
-	_, ok := data.(jsonObject)
-	return ok
+	switch object := data.(type) {
+	case jsonObject:
+		return true
+	case Node:
+		return object.Kind() == ObjectKind
+	default:
+		return false
+	}
 }
 
-func getIndex(data jsonNode, index int) (jsonNode, bool) {
-	array, ok := data.(jsonArray)
+// getIndex will return the item at the provided index of the array along with
+// the index itself. Negative indexes are relative to the end of the array, in
+// which case the returned index is the positive index of the item. If the
+// index is out of bounds then false is returned.
+func getIndex(data jsonNode, index int) (jsonNode, int, bool) {
+	length, ok := arrayLength(data)
 	if !ok {
+		return nil, index, false
+	}
+
+	if index < 0 {
+		index += length
+	}
+
+	if index < 0 || index >= length {
+		return nil, index, false
+	}
+
+	return elementAt(data, index), index, true
+}
+
+// nodesOf returns the nodes that were selected by the previous step of an
+// evaluation. Nodes that were selected by a recursive decent are stored in a
+// jsonMutatedArray instead of a nodeList, in which case recursive will be
+// true.
+func nodesOf(data jsonNode) (nodes []locatedNode, recursive bool) {
+	switch list := data.(type) {
+	case nodeList:
+		return list, false
+	case jsonMutatedArray:
+		return list, true
+	default:
 		return nil, false
 	}
+}
+
+// valuesOf returns just the values of the nodes.
+func valuesOf(nodes []locatedNode) []interface{} {
+	values := make([]interface{}, len(nodes))
+	for i, node := range nodes {
+		values[i] = node.value
+	}
+
+	return values
+}
+
+// sortedKeys returns the keys of the object in order. Objects are unordered
+// so this is used to keep results consistent between evaluations.
+func sortedKeys(data jsonObject) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	return jsonNode(array[index]), true
+	return keys
 }
@@ -1,27 +1,48 @@
 package jsonpath
 
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
 type (
 	// Evaluator is a compiled form of a jsonpath. It can run it's jsonpath
 	// against any provided json object and only needs to parse the provided
-	// json.
+	// json. An Evaluator is safe for concurrent use.
 	Evaluator struct {
-		path    string
-		actions []jsonAction
+		path     string
+		compiled compiledJsonPath
+		numbers  NumberMode
+		limits   *Limits
+
+		// decoder parses the documents that are evaluated, it is nil if they
+		// are json.
+		decoder Decoder
 	}
 
 	evalContext struct {
 		parent *evalContext
 		data   jsonNode
+
+		// cancel is shared by every context of an evaluation, it is nil if
+		// the evaluation cannot be cancelled.
+		cancel *cancellation
+
+		// limits are the limits of the Evaluator, they are nil if the
+		// evaluation is not limited.
+		limits *Limits
 	}
 )
 
-// Jsonpath will evaluate the provided jsonpath on the provided json. It does
-// not cache either of these parameters. If you are using the same jsonpath
-// consistently then you would want to create an Evaluator for that path
+// Jsonpath will evaluate the provided jsonpath on the provided json. The
+// compiled jsonpath is kept in the DefaultCache, so evaluating the same
+// jsonpath again does not need to parse it. If you are using the same jsonpath
+// consistently then you may want to create an Evaluator for that path
 // instead. An error is returned if there is a problem parsing the jsonpath or
 // if the json could not be parsed.
 func Jsonpath(data []byte, path string) ([]interface{}, error) {
-	eval, err := NewEvaluator(path)
+	eval, err := DefaultCache.Get(path)
 	if err != nil {
 		return nil, err
 	}
@@ -31,50 +52,515 @@ func Jsonpath(data []byte, path string) ([]interface{}, error) {
 
 // NewEvaluator will compile the provided jsonpath and create an object that can
 // run that expression on provided json objects. If the path is not valid then
-// an error is returned.
-func NewEvaluator(path string) (*Evaluator, error) {
-	actions, err := parsePath(path)
+// an error is returned. Options can be provided to change how the jsonpath is
+// evaluated.
+func NewEvaluator(path string, options ...Option) (*Evaluator, error) {
+	eval := &Evaluator{
+		path: path,
+	}
+	eval.apply(options)
+
+	// The length is checked before parsing so that long paths are not parsed.
+	if err := eval.limits.checkPathLength(path); err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseLimited(path, eval.limits)
 	if err != nil {
 		return nil, err
 	}
 
+	if eval.compiled, err = eval.compile(parsed); err != nil {
+		return nil, err
+	}
+
+	return eval, nil
+}
+
+// MustCompile is like NewEvaluator but will panic if the path is not valid.
+// It is intended for initializing package level variables with jsonpaths
+// that are known to be valid.
+func MustCompile(path string, options ...Option) *Evaluator {
+	eval, err := NewEvaluator(path, options...)
+	if err != nil {
+		panic(`jsonpath: MustCompile(` + quoteString(path) + `): ` + err.Error())
+	}
+
+	return eval
+}
+
+// newPathEvaluator will create an evaluator from an already parsed path. The
+// canonical form of the path is used as the evaluator's jsonpath.
+func newPathEvaluator(path *Path, options []Option) (*Evaluator, error) {
 	eval := &Evaluator{
-		path:    path,
-		actions: actions.actions,
+		path: path.String(),
+	}
+	eval.apply(options)
+
+	if err := eval.limits.checkPathLength(eval.path); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if eval.compiled, err = eval.compile(path); err != nil {
+		return nil, err
 	}
 
 	return eval, nil
 }
 
+func (e *Evaluator) apply(options []Option) {
+	for _, option := range options {
+		option(e)
+	}
+}
+
+// compile will compile the path once it is known to be within the limits.
+func (e *Evaluator) compile(path *Path) (compiledJsonPath, error) {
+	if err := e.limits.checkPath(path); err != nil {
+		return compiledJsonPath{}, err
+	}
+
+	compiled, err := compilePath(path)
+	if err != nil {
+		return compiledJsonPath{}, err
+	}
+
+	// Only json can be scanned, anything else has to be decoded.
+	if e.decoder != nil {
+		compiled.scannable = false
+	}
+
+	return compiled, nil
+}
+
 // Evaluate will run the compiled jsonpath against the provided json. It will
 // return an array of objects that is the result of the expression or an error
 // if something failed to evaluate or if the json was invalid.
 func (e *Evaluator) Evaluate(data []byte) ([]interface{}, error) {
-	node, err := parseJson(data)
+	if e.compiled.scannable {
+		return e.scan(data, nil)
+	}
+
+	node, err := e.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := e.run(locatedNode{value: node}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return valuesOf(nodes), nil
+}
+
+// EvaluateRaw will run the compiled jsonpath against the provided json like
+// Evaluate, but instead of decoding the results it returns the raw json of
+// each of them. Each result is the exact bytes from the provided json, so
+// numbers keep their formatting and objects keep the order of their keys.
+// The results share memory with data, so data should not be modified while
+// the results are in use.
+func (e *Evaluator) EvaluateRaw(data []byte) ([]json.RawMessage, error) {
+	if err := e.checkJSONInput("raw results"); err != nil {
+		return nil, err
+	}
+
+	if e.compiled.scannable {
+		return e.scanRaw(data, nil)
+	}
+
+	if e.compiled.function != nil {
+		// The result of a function is not in the json, so it is encoded.
+		values, err := e.Evaluate(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return encodeRaw(values)
+	}
+
+	node, err := e.decode(data)
 	if err != nil {
 		return nil, err
 	}
 
-	return e.run(jsonArray{node})
+	// Track where each result is so that it can be found in the raw json.
+	nodes, err := e.run(locatedNode{value: node, location: rootLocation()}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]*location, len(nodes))
+	for i, node := range nodes {
+		locations[i] = node.location
+	}
+
+	return locateRaw(data, locations), nil
 }
 
-func (e *Evaluator) run(root jsonNode) ([]interface{}, error) {
-	ctx := &evalContext{
+// First will run the compiled jsonpath against the provided json and return
+// the first value that it matches. ErrNoMatch is returned if the jsonpath does
+// not match anything. Evaluation stops as soon as the first value is found,
+// so errors that would only occur later in the document are not returned.
+func (e *Evaluator) First(data []byte) (interface{}, error) {
+	var first interface{}
+	found := false
+	err := e.stream(data, func(value func() (jsonNode, error)) (bool, error) {
+		var err error
+		first, err = value()
+		found = true
+
+		return false, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrNoMatch
+	}
+
+	return first, nil
+}
+
+// One will run the compiled jsonpath against the provided json and return the
+// value that it matches. ErrNoMatch or ErrMultipleMatches is returned if the
+// jsonpath does not match exactly one value. Evaluation stops as soon as a
+// second value is found.
+func (e *Evaluator) One(data []byte) (interface{}, error) {
+	var one interface{}
+	count := 0
+	err := e.stream(data, func(value func() (jsonNode, error)) (bool, error) {
+		count++
+		if count > 1 {
+			return false, nil
+		}
+
+		var err error
+		one, err = value()
+
+		return true, err
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case count == 0:
+		return nil, ErrNoMatch
+	case count > 1:
+		return nil, ErrMultipleMatches
+	default:
+		return one, nil
+	}
+}
+
+// Exists will run the compiled jsonpath against the provided json and return
+// true if it matches anything. Evaluation stops as soon as a value is found.
+func (e *Evaluator) Exists(data []byte) (bool, error) {
+	found := false
+	err := e.stream(data, func(func() (jsonNode, error)) (bool, error) {
+		found = true
+		return false, nil
+	})
+
+	return found, err
+}
+
+// Count will run the compiled jsonpath against the provided json and return
+// the number of values that it matches. None of the values are collected or
+// decoded.
+func (e *Evaluator) Count(data []byte) (int, error) {
+	count := 0
+	err := e.stream(data, func(func() (jsonNode, error)) (bool, error) {
+		count++
+		return true, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Walk will run the compiled jsonpath against the provided json and call fn
+// with the normalized path and value of each match as it is found, for example
+// `$['store']['book'][0]`. The matches are not collected, and evaluation stops
+// as soon as fn returns false. Walk can be used with any version of Go, the
+// Matches iterator requires Go 1.23 or later.
+func (e *Evaluator) Walk(data []byte, fn func(path string, value interface{}) bool) error {
+	if err := e.checkLocations(); err != nil {
+		return err
+	}
+
+	node, err := e.decode(data)
+	if err != nil {
+		return err
+	}
+
+	ctx := e.newContext(locatedNode{value: node, location: rootLocation()}, nil)
+	results := 0
+	_, err = streamActions(ctx, e.compiled.actions, func(node locatedNode) (bool, error) {
+		results++
+		if err := e.limits.checkResults(results); err != nil {
+			return false, err
+		}
+
+		return fn(node.location.String(), node.value), nil
+	})
+
+	return err
+}
+
+// stream will call yield with each of the values that the jsonpath matches as
+// they are found. The values are only decoded when value is called, so that
+// they are not decoded if they are not used. Evaluation stops as soon as
+// yield returns false or an error.
+func (e *Evaluator) stream(data []byte, yield func(value func() (jsonNode, error)) (bool, error)) error {
+	results := 0
+	limitedYield := func(value func() (jsonNode, error)) (bool, error) {
+		results++
+		if err := e.limits.checkResults(results); err != nil {
+			return false, err
+		}
+
+		return yield(value)
+	}
+
+	if e.compiled.scannable {
+		if err := e.limits.checkInputSize(data); err != nil {
+			return err
+		}
+
+		return e.compiled.raw.stream(data, nil, func(match json.RawMessage) (bool, error) {
+			return limitedYield(func() (jsonNode, error) {
+				return decodeJson(match, e.numbers)
+			})
+		})
+	}
+
+	node, err := e.decode(data)
+	if err != nil {
+		return err
+	}
+
+	if e.compiled.function != nil {
+		// The function needs every result before it has its own.
+		nodes, err := e.run(locatedNode{value: node}, nil)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			value := node.value
+			ok, err := limitedYield(func() (jsonNode, error) {
+				return value, nil
+			})
+			if !ok || err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	ctx := e.newContext(locatedNode{value: node}, nil)
+	_, err = streamActions(ctx, e.compiled.actions, func(node locatedNode) (bool, error) {
+		return limitedYield(func() (jsonNode, error) {
+			return node.value, nil
+		})
+	})
+
+	return err
+}
+
+func (e *Evaluator) run(root locatedNode, cancel *cancellation) ([]locatedNode, error) {
+	result, err := runActions(e.newContext(root, cancel), e.compiled.actions)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, _ := nodesOf(result)
+	if e.compiled.function != nil {
+		return e.compiled.function.apply(nodes, e.numbers), nil
+	}
+
+	return nodes, nil
+}
+
+// checkLocations will return an error if the jsonpath ends with a function,
+// since the result of a function is not anywhere in the document.
+func (e *Evaluator) checkLocations() error {
+	if e.compiled.function != nil {
+		return errors.Errorf("the result of %s() does not have a location", e.compiled.function.function)
+	}
+
+	return nil
+}
+
+// newContext returns the context that an evaluation of the root node starts
+// with.
+func (e *Evaluator) newContext(root locatedNode, cancel *cancellation) *evalContext {
+	return &evalContext{
 		parent: nil,
-		data:   root,
+		data:   nodeList{root},
+		cancel: cancel,
+		limits: e.limits,
+	}
+}
+
+// decode will parse the document once it is known to be within the limits.
+func (e *Evaluator) decode(data []byte) (jsonNode, error) {
+	if err := e.limits.checkInputSize(data); err != nil {
+		return nil, err
 	}
 
-	for _, action := range e.actions {
+	if e.decoder != nil {
+		return e.decoder.Decode(data, e.numbers)
+	}
+
+	return decodeJson(data, e.numbers)
+}
+
+// scanRaw will scan the json for the raw values selected by the path.
+func (e *Evaluator) scanRaw(data []byte, cancel *cancellation) ([]json.RawMessage, error) {
+	if err := e.limits.checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	matches, err := e.compiled.raw.scan(data, cancel)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.limits.checkResults(len(matches)); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// scan will evaluate simple paths by scanning the json for the selected values
+// instead of parsing all of it. Only the values that are selected are parsed.
+func (e *Evaluator) scan(data []byte, cancel *cancellation) ([]interface{}, error) {
+	matches, err := e.scanRaw(data, cancel)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(matches))
+	for i, match := range matches {
+		if err := cancel.check(); err != nil {
+			return nil, err
+		}
+
+		if items[i], err = decodeJson(match, e.numbers); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+// child returns the context for the next step of the evaluation.
+func (c *evalContext) child(data jsonNode) *evalContext {
+	return &evalContext{
+		parent: c,
+		data:   data,
+		cancel: c.cancel,
+		limits: c.limits,
+	}
+}
+
+// runActions will execute each of the actions in order, each action is given
+// the result of the previous action. The result of the last action is
+// returned.
+func runActions(ctx *evalContext, actions []jsonAction) (jsonNode, error) {
+	ctx, err := executeActions(ctx, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.data, nil
+}
+
+// executeActions is like runActions but returns the context of the last
+// action, so that more actions can be run after it.
+func executeActions(ctx *evalContext, actions []jsonAction) (*evalContext, error) {
+	for _, action := range actions {
 		result, err := action.Execute(ctx)
 		if err != nil {
 			return nil, err
 		}
 
-		ctx = &evalContext{
-			parent: ctx,
-			data:   result,
+		nodes, _ := nodesOf(result)
+		if err := ctx.limits.checkResults(len(nodes)); err != nil {
+			return nil, err
+		}
+
+		ctx = ctx.child(result)
+	}
+
+	return ctx, nil
+}
+
+// streamActions will run the actions like runActions, but each node that an
+// action selects is passed through the rest of the actions before the next
+// one. This produces the nodes in the same order while allowing the
+// evaluation to stop as soon as yield returns false or an error.
+func streamActions(ctx *evalContext, actions []jsonAction, yield func(node locatedNode) (bool, error)) (bool, error) {
+	if len(actions) == 0 {
+		nodes, _ := nodesOf(ctx.data)
+		for _, node := range nodes {
+			if ok, err := yield(node); !ok || err != nil {
+				return ok, err
+			}
+		}
+
+		return true, nil
+	}
+
+	next := func(data jsonNode) (bool, error) {
+		return streamActions(ctx.child(data), actions[1:], yield)
+	}
+
+	// Recursive decent is walked instead of being collected, since it may
+	// select every node in the document.
+	if recursive, ok := actions[0].(recursiveAction); ok {
+		nodes, _ := nodesOf(ctx.data)
+		for _, node := range nodes {
+			ok, err := recursive.walk(ctx, node, 0, func(node locatedNode) (bool, error) {
+				return next(jsonMutatedArray{node})
+			})
+			if !ok || err != nil {
+				return ok, err
+			}
+		}
+
+		return true, nil
+	}
+
+	result, err := actions[0].Execute(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	nodes, recursive := nodesOf(result)
+	for _, node := range nodes {
+		if err := ctx.cancel.check(); err != nil {
+			return false, err
+		}
+
+		// Keep the node in the same kind of list so that the next action
+		// still knows whether it is part of a recursive decent.
+		var data jsonNode = nodeList{node}
+		if recursive {
+			data = jsonMutatedArray{node}
+		}
+
+		if ok, err := next(data); !ok || err != nil {
+			return ok, err
 		}
 	}
 
-	return ctx.data.([]interface{}), nil
+	return true, nil
 }
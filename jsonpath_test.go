@@ -144,14 +144,14 @@ func TestEvaluator_Evaluate(t *testing.T) {
 		}, result)
 	})
 
-	t.Run("array index fails on object", func(t *testing.T) {
-		err := MustFailOnTestJson(t, "[0]")
-		assert.EqualError(t, err, "item is not an array")
+	t.Run("array index on object", func(t *testing.T) {
+		result := EvaluateOnTestJson(t, "[0]")
+		AssertResult(t, []I{}, result)
 	})
 
-	t.Run("array index list fails on object", func(t *testing.T) {
-		err := MustFailOnTestJson(t, "[0,1]")
-		assert.EqualError(t, err, "item is not an array")
+	t.Run("array index list on object", func(t *testing.T) {
+		result := EvaluateOnTestJson(t, "[0,1]")
+		AssertResult(t, []I{}, result)
 	})
 
 	t.Run("cannot access field on non-mutated array", func(t *testing.T) {
@@ -209,6 +209,14 @@ func TestEvaluator_Evaluate(t *testing.T) {
 		}, result)
 	})
 
+	t.Run("whitespace in brackets", func(t *testing.T) {
+		result := EvaluateOnTestJson(t, "$[ 'firstName' ,\n  'lastName' ]")
+		assertMatchingStringArray(t, []string{
+			"John",
+			"doe",
+		}, result)
+	})
+
 	t.Run("wildcard object", func(t *testing.T) {
 		result := EvaluateOnTestJson(t, "$.address.*")
 		assertMatchingStringArray(t, []string{
@@ -219,6 +227,547 @@ func TestEvaluator_Evaluate(t *testing.T) {
 	})
 }
 
+const StoreJson = `{
+  "store": {
+    "book": [
+      {
+        "category": "reference",
+        "author": "Nigel Rees",
+        "title": "Sayings of the Century",
+        "price": 8.95
+      },
+      {
+        "category": "fiction",
+        "author": "Evelyn Waugh",
+        "title": "Sword of Honour",
+        "price": 12.99
+      },
+      {
+        "category": "fiction",
+        "author": "Herman Melville",
+        "title": "Moby Dick",
+        "isbn": "0-553-21311-3",
+        "price": 8.99
+      },
+      {
+        "category": "fiction",
+        "author": "J. R. R. Tolkien",
+        "title": "The Lord of the Rings",
+        "isbn": "0-395-19395-8",
+        "price": 22.99
+      }
+    ],
+    "bicycle": {
+      "color": "red",
+      "price": 19.95
+    }
+  },
+  "expensive": 10
+}`
+
+func EvaluateOnStoreJson(t *testing.T, path string) []interface{} {
+	result, err := Jsonpath([]byte(StoreJson), path)
+	require.NoError(t, err, "should succeed")
+	return result
+}
+
+func TestEvaluator_Slices(t *testing.T) {
+	t.Run("negative index", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[-1].title")
+		AssertResult(t, []I{
+			"The Lord of the Rings",
+		}, result)
+	})
+
+	t.Run("index out of range", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[4,-5].title")
+		AssertResult(t, []I{}, result)
+	})
+
+	t.Run("union with an index out of range", func(t *testing.T) {
+		// Indexes that are out of range select nothing instead of failing.
+		result := EvaluateOnStoreJson(t, "$.store.book[0,9].title")
+		AssertResult(t, []I{
+			"Sayings of the Century",
+		}, result)
+	})
+
+	t.Run("doubled quotes", func(t *testing.T) {
+		result, err := Jsonpath([]byte(`{"it's": 1, "say \"hi\"": 2}`), `$['it''s', "say ""hi"""]`)
+		require.NoError(t, err)
+		AssertResult(t, []I{1.0, 2.0}, result)
+	})
+
+	t.Run("recursive index", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$..book[2].title")
+		AssertResult(t, []I{
+			"Moby Dick",
+		}, result)
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[1:3].title")
+		AssertResult(t, []I{
+			"Sword of Honour",
+			"Moby Dick",
+		}, result)
+	})
+
+	t.Run("slice defaults", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[:2].title")
+		AssertResult(t, []I{
+			"Sayings of the Century",
+			"Sword of Honour",
+		}, result)
+
+		result = EvaluateOnStoreJson(t, "$.store.book[-1:].title")
+		AssertResult(t, []I{
+			"The Lord of the Rings",
+		}, result)
+	})
+
+	t.Run("slice with step", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[::2].title")
+		AssertResult(t, []I{
+			"Sayings of the Century",
+			"Moby Dick",
+		}, result)
+	})
+
+	t.Run("reverse slice", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[::-1].title")
+		AssertResult(t, []I{
+			"The Lord of the Rings",
+			"Moby Dick",
+			"Sword of Honour",
+			"Sayings of the Century",
+		}, result)
+	})
+
+	t.Run("zero step", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[::0].title")
+		AssertResult(t, []I{}, result)
+	})
+
+	t.Run("union", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[0,2:].price")
+		AssertResult(t, []I{
+			8.95,
+			8.99,
+			22.99,
+		}, result)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		// Index and slice selectors select nothing from anything that is not
+		// an array, as in RFC 9535.
+		paths := map[string][]I{
+			"$.store.bicycle[0]":       {},
+			"$.store.bicycle[0:1]":     {},
+			"$.store.bicycle[0,1]":     {},
+			"$.store.*[0].title":       {"Sayings of the Century"},
+			"$.store..*[1:2].title":    {"Sword of Honour"},
+			"$..*[0].author":           {"Nigel Rees"},
+			"$.store.book[0].price[0]": {},
+		}
+		for path, expected := range paths {
+			result := EvaluateOnStoreJson(t, path)
+			AssertResult(t, expected, result)
+		}
+
+		result, err := Jsonpath([]byte(`[[1], 2, {"a": [3]}, "b"]`), "$[*][0]")
+		require.NoError(t, err)
+		AssertResult(t, []I{1.0}, result)
+
+		result, err = Jsonpath([]byte(`{"a": [1, 2], "b": {"a": "c"}}`), "$..a[0:1]")
+		require.NoError(t, err)
+		AssertResult(t, []I{1.0}, result)
+	})
+
+	t.Run("mixed union", func(t *testing.T) {
+		// Each selector of a union skips the nodes that it does not apply to.
+		result, err := Jsonpath([]byte(`{"a": 1}`), "$[0,'a']")
+		require.NoError(t, err)
+		AssertResult(t, []I{1.0}, result)
+
+		result, err = Jsonpath([]byte(`[{"a": 1}, [2], 3]`), "$[*][0,'a']")
+		require.NoError(t, err)
+		AssertResult(t, []I{1.0, 2.0}, result)
+
+		result, err = Jsonpath([]byte(`[{"a": 1}, [2], 3]`), "$[*][1:,'a']")
+		require.NoError(t, err)
+		AssertResult(t, []I{1.0}, result)
+	})
+}
+
+func TestEvaluator_Descendants(t *testing.T) {
+	const nested = `{"x": {"x": {"x": 1}}, "list": [{"x": 2}, [{"x": 3}]]}`
+
+	t.Run("document order", func(t *testing.T) {
+		// Each node comes before its descendants and the members of objects
+		// are visited in order of their names, as in RFC 9535. This used to
+		// return the deepest nodes first.
+		result, err := Jsonpath([]byte(nested), "$..x")
+		require.NoError(t, err)
+		AssertResult(t, []I{
+			map[string]interface{}{"x": map[string]interface{}{"x": 1.0}},
+			2.0,
+			3.0,
+			map[string]interface{}{"x": 1.0},
+			1.0,
+		}, result)
+	})
+
+	t.Run("includes the node itself", func(t *testing.T) {
+		result, err := Jsonpath([]byte(nested), "$.x..x")
+		require.NoError(t, err)
+		AssertResult(t, []I{
+			map[string]interface{}{"x": 1.0},
+			1.0,
+		}, result)
+	})
+
+	t.Run("same order every time", func(t *testing.T) {
+		expected := EvaluateOnStoreJson(t, "$..*")
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, expected, EvaluateOnStoreJson(t, "$..*"))
+		}
+	})
+
+	t.Run("scalars", func(t *testing.T) {
+		result, err := Jsonpath([]byte(`5`), "$..*")
+		require.NoError(t, err)
+		AssertResult(t, []I{}, result)
+	})
+}
+
+func TestEvaluator_Filters(t *testing.T) {
+	t.Run("existence", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$..book[?(@.isbn)].title")
+		AssertResult(t, []I{
+			"Moby Dick",
+			"The Lord of the Rings",
+		}, result)
+	})
+
+	t.Run("not existence", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[?!@.isbn].title")
+		AssertResult(t, []I{
+			"Sayings of the Century",
+			"Sword of Honour",
+		}, result)
+	})
+
+	t.Run("comparison", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[?(@.price < 10)].title")
+		AssertResult(t, []I{
+			"Sayings of the Century",
+			"Moby Dick",
+		}, result)
+	})
+
+	t.Run("string comparison", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[?@.category == 'reference'].author")
+		AssertResult(t, []I{
+			"Nigel Rees",
+		}, result)
+	})
+
+	t.Run("absolute comparison", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[?(@.price > $.expensive)].title")
+		AssertResult(t, []I{
+			"Sword of Honour",
+			"The Lord of the Rings",
+		}, result)
+	})
+
+	t.Run("logical operators", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[?@.category == 'fiction' && (@.price <= 8.99 || @.price >= 20)].title")
+		AssertResult(t, []I{
+			"Moby Dick",
+			"The Lord of the Rings",
+		}, result)
+	})
+
+	t.Run("object members", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store[?@.color == 'red'].price")
+		AssertResult(t, []I{
+			19.95,
+		}, result)
+	})
+
+	t.Run("missing values", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, "$.store.book[?@.missing == $.nothing].title")
+		assert.Len(t, result, 4)
+
+		result = EvaluateOnStoreJson(t, "$.store.book[?@.missing < 1].title")
+		assert.Empty(t, result)
+	})
+
+	t.Run("multi-line", func(t *testing.T) {
+		result := EvaluateOnStoreJson(t, `$.store.book[?
+			@.category == 'fiction' &&
+			@.price < 10
+		].title`)
+		AssertResult(t, []I{
+			"Moby Dick",
+		}, result)
+	})
+}
+
+func TestEvaluator_EvaluateRaw(t *testing.T) {
+	data := []byte(`{
+		"items": [
+			{"id": 1.50, "tags": ["a", "b"]},
+			{"name": "second", "id": 2},
+			{"id": 3e2, "nested": {"z": 1, "a": 2}}
+		],
+		"dup": 1,
+		"dup": {"b": 1, "a": 2}
+	}`)
+
+	evaluate := func(t *testing.T, path string) []string {
+		eval, err := NewEvaluator(path)
+		require.NoError(t, err, path)
+
+		raw, err := eval.EvaluateRaw(data)
+		require.NoError(t, err, path)
+
+		result := make([]string, len(raw))
+		for i, item := range raw {
+			result[i] = string(item)
+		}
+
+		return result
+	}
+
+	t.Run("scanned path", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`1.50`,
+			`2`,
+			`3e2`,
+		}, evaluate(t, "$.items[*].id"))
+	})
+
+	t.Run("keeps key order", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`{"z": 1, "a": 2}`,
+		}, evaluate(t, "$..nested"))
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`1.50`,
+			`2`,
+			`3e2`,
+		}, evaluate(t, "$..id"))
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`{"name": "second", "id": 2}`,
+			`{"id": 3e2, "nested": {"z": 1, "a": 2}}`,
+		}, evaluate(t, "$.items[?@.id > 1.5]"))
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`2`,
+		}, evaluate(t, "$..dup.a"))
+	})
+
+	t.Run("same node selected twice", func(t *testing.T) {
+		assert.Equal(t, []string{
+			`"b"`,
+			`"a"`,
+			`"b"`,
+		}, evaluate(t, "$.items[0].tags[1:,0,-1]"))
+	})
+
+	t.Run("root", func(t *testing.T) {
+		assert.Equal(t, []string{
+			string(data),
+		}, evaluate(t, "$"))
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		eval, err := NewEvaluator("$..id")
+		require.NoError(t, err)
+
+		raw, err := eval.EvaluateRaw([]byte(`{"id":`))
+		assert.Error(t, err)
+		assert.Nil(t, raw)
+	})
+
+	t.Run("raw matches decoded", func(t *testing.T) {
+		eval, err := NewEvaluator("$.store..[?@.price < 10]")
+		require.NoError(t, err)
+
+		raw, err := eval.EvaluateRaw([]byte(StoreJson))
+		require.NoError(t, err)
+
+		decoded, err := eval.Evaluate([]byte(StoreJson))
+		require.NoError(t, err)
+
+		require.Len(t, raw, len(decoded))
+		for i, item := range raw {
+			var value interface{}
+			require.NoError(t, json.Unmarshal(item, &value))
+			assert.Equal(t, decoded[i], value)
+		}
+	})
+}
+
+func TestEvaluator_Queries(t *testing.T) {
+	data := []byte(StoreJson)
+
+	t.Run("first", func(t *testing.T) {
+		result, err := MustCompile("$..author").First(data)
+		require.NoError(t, err)
+		assert.Equal(t, "Nigel Rees", result)
+
+		result, err = MustCompile("$.store.book[?@.price > 10].title").First(data)
+		require.NoError(t, err)
+		assert.Equal(t, "Sword of Honour", result)
+
+		result, err = MustCompile("$.missing").First(data)
+		assert.Equal(t, ErrNoMatch, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("one", func(t *testing.T) {
+		result, err := MustCompile("$..bicycle.color").One(data)
+		require.NoError(t, err)
+		assert.Equal(t, "red", result)
+
+		_, err = MustCompile("$..author").One(data)
+		assert.Equal(t, ErrMultipleMatches, err)
+
+		_, err = MustCompile("$..missing").One(data)
+		assert.Equal(t, ErrNoMatch, err)
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		ok, err := MustCompile("$..book[?@.isbn]").Exists(data)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = MustCompile("$..book[?@.price > 100]").Exists(data)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		count, err := MustCompile("$..price").Count(data)
+		require.NoError(t, err)
+		assert.Equal(t, 5, count)
+
+		count, err = MustCompile("$.store.book[*]").Count(data)
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("stops at the first match", func(t *testing.T) {
+		// Evaluating all of this path exceeds the limit because there are two
+		// elements, but the second element is never reached.
+		data := []byte(`[[1], [2]]`)
+		eval := MustCompile("$[*][0]", WithLimits(Limits{MaxResults: 1}))
+
+		_, err := eval.Evaluate(data)
+		assert.EqualError(t, err, "results limit of 1 exceeded")
+
+		result, err := eval.First(data)
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), result)
+
+		_, err = eval.Count(data)
+		assert.EqualError(t, err, "results limit of 1 exceeded")
+	})
+
+	t.Run("same order as evaluate", func(t *testing.T) {
+		paths := []string{
+			"$..*",
+			"$..[0,'price']",
+			"$.store..[?@.price < 20]",
+			"$..book[-1:0:-1].title",
+			"$.store.*",
+		}
+		for _, path := range paths {
+			eval := MustCompile(path)
+			expected, err := eval.Evaluate(data)
+			require.NoError(t, err, path)
+
+			result := make([]interface{}, 0)
+			err = eval.stream(data, func(value func() (jsonNode, error)) (bool, error) {
+				item, err := value()
+				result = append(result, item)
+				return true, err
+			})
+			require.NoError(t, err, path)
+			assert.Equal(t, expected, result, path)
+		}
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		ok, err := MustCompile("$..test").Exists([]byte(`{"test:true}`))
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestEvaluator_Walk(t *testing.T) {
+	data := []byte(StoreJson)
+
+	t.Run("paths and values", func(t *testing.T) {
+		paths := make([]string, 0)
+		values := make([]interface{}, 0)
+		err := MustCompile("$..book[?@.price < 10].title").Walk(data, func(path string, value interface{}) bool {
+			paths = append(paths, path)
+			values = append(values, value)
+			return true
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"$['store']['book'][0]['title']",
+			"$['store']['book'][2]['title']",
+		}, paths)
+		assert.Equal(t, []interface{}{
+			"Sayings of the Century",
+			"Moby Dick",
+		}, values)
+	})
+
+	t.Run("scannable path", func(t *testing.T) {
+		paths := make([]string, 0)
+		err := MustCompile("$.store.*").Walk(data, func(path string, value interface{}) bool {
+			paths = append(paths, path)
+			return true
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"$['store']['bicycle']",
+			"$['store']['book']",
+		}, paths)
+	})
+
+	t.Run("stop early", func(t *testing.T) {
+		count := 0
+		err := MustCompile("$..*").Walk(data, func(path string, value interface{}) bool {
+			count++
+			return count < 3
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := MustCompile("$..*", WithLimits(Limits{MaxResults: 1})).Walk(data, func(string, interface{}) bool {
+			return true
+		})
+		assert.EqualError(t, err, "results limit of 1 exceeded")
+	})
+}
+
 func TestJsonpath(t *testing.T) {
 	t.Run("bad path", func(t *testing.T) {
 		result, err := Jsonpath(nil, `"thing`)
@@ -0,0 +1,193 @@
+package jsonpath
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrLimitExceeded is returned, wrapped in a LimitError, when a jsonpath or
+// the json it is evaluated against exceeds one of the Limits of an Evaluator.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+type (
+	// Limits restrict the resources that are used to compile and evaluate a
+	// jsonpath, so that paths and documents from untrusted sources can be
+	// evaluated safely. A limit of 0 means that there is no limit.
+	Limits struct {
+		// MaxPathLength is the maximum length of the jsonpath in bytes.
+		MaxPathLength int
+
+		// MaxSelectors is the maximum number of selectors in the jsonpath,
+		// including the selectors of queries within filters.
+		MaxSelectors int
+
+		// MaxFilterDepth is the maximum depth of the expressions within a
+		// filter. Each logical operator, comparison and nested filter adds a
+		// level. While the jsonpath is parsed each negation, parenthesis and
+		// nested filter adds a level, so that deeply nested paths are
+		// rejected before they are parsed.
+		MaxFilterDepth int
+
+		// MaxInputSize is the maximum size of the json in bytes.
+		MaxInputSize int
+
+		// MaxDepth is the maximum depth below a node that a recursive decent
+		// will descend to.
+		MaxDepth int
+
+		// MaxResults is the maximum number of nodes that each step of the
+		// evaluation can select, including the results.
+		MaxResults int
+	}
+
+	// LimitError is returned when one of the Limits is exceeded. Limit is the
+	// name of the limit and Max is its value.
+	LimitError struct {
+		Limit string
+		Max   int
+	}
+)
+
+// WithLimits will restrict the resources that the Evaluator can use. The
+// jsonpath must be within the limits for the Evaluator to be created, and any
+// evaluation that exceeds them will return a LimitError.
+func WithLimits(limits Limits) Option {
+	return func(e *Evaluator) {
+		e.limits = &limits
+	}
+}
+
+// Error returns a description of the limit that was exceeded.
+func (l *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded", l.Limit, l.Max)
+}
+
+// Unwrap allows a LimitError to be matched with errors.Is(err,
+// ErrLimitExceeded).
+func (l *LimitError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// exceeds will return a LimitError if value is more than max, unless max is
+// 0.
+func exceeds(limit string, value, max int) error {
+	if max > 0 && value > max {
+		return &LimitError{
+			Limit: limit,
+			Max:   max,
+		}
+	}
+
+	return nil
+}
+
+func (l *Limits) checkPathLength(path string) error {
+	if l == nil {
+		return nil
+	}
+
+	return exceeds("path length", len(path), l.MaxPathLength)
+}
+
+// checkPath will make sure that the parsed path does not have too many
+// selectors or filters that are nested too deeply.
+func (l *Limits) checkPath(path *Path) error {
+	if l == nil {
+		return nil
+	}
+
+	selectors, depth := measureSegments(path.Segments)
+	if err := exceeds("selectors", selectors, l.MaxSelectors); err != nil {
+		return err
+	}
+
+	return l.checkFilterDepth(depth)
+}
+
+func (l *Limits) checkFilterDepth(depth int) error {
+	if l == nil {
+		return nil
+	}
+
+	return exceeds("filter depth", depth, l.MaxFilterDepth)
+}
+
+func (l *Limits) checkInputSize(data []byte) error {
+	if l == nil {
+		return nil
+	}
+
+	return exceeds("input size", len(data), l.MaxInputSize)
+}
+
+func (l *Limits) checkDepth(depth int) error {
+	if l == nil {
+		return nil
+	}
+
+	return exceeds("depth", depth, l.MaxDepth)
+}
+
+func (l *Limits) checkResults(results int) error {
+	if l == nil {
+		return nil
+	}
+
+	return exceeds("results", results, l.MaxResults)
+}
+
+// measureSegments returns the number of selectors within the segments and the
+// depth of the deepest filter expression.
+func measureSegments(segments []Segment) (selectors, depth int) {
+	for _, segment := range segments {
+		for _, selector := range segment.Selectors() {
+			selectors++
+
+			filter, ok := selector.(FilterSelector)
+			if !ok {
+				continue
+			}
+
+			filterSelectors, filterDepth := measureExpression(filter.Expression)
+			selectors += filterSelectors
+			if filterDepth > depth {
+				depth = filterDepth
+			}
+		}
+	}
+
+	return selectors, depth
+}
+
+// measureExpression returns the number of selectors within the expression's
+// queries and the depth of the expression.
+func measureExpression(expression Expression) (selectors, depth int) {
+	var inner []Expression
+	switch e := expression.(type) {
+	case OrExpression:
+		inner = e
+	case AndExpression:
+		inner = e
+	case NotExpression:
+		inner = []Expression{e.Expression}
+	case ComparisonExpression:
+		inner = []Expression{e.Left, e.Right}
+	case QueryExpression:
+		// Filters within the query are nested within this expression.
+		selectors, depth = measureSegments(e.Segments)
+		return selectors, depth + 1
+	default:
+		return 0, 1
+	}
+
+	for _, expression := range inner {
+		innerSelectors, innerDepth := measureExpression(expression)
+		selectors += innerSelectors
+		if innerDepth > depth {
+			depth = innerDepth
+		}
+	}
+
+	return selectors, depth + 1
+}
@@ -0,0 +1,136 @@
+package jsonpath
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLimits(t *testing.T) {
+	assertLimit := func(t *testing.T, err error, limit string, max int) {
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrLimitExceeded), err.Error())
+
+		var limitErr *LimitError
+		require.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, limit, limitErr.Limit)
+		assert.Equal(t, max, limitErr.Max)
+	}
+
+	t.Run("path length", func(t *testing.T) {
+		_, err := NewEvaluator("$.store.book", WithLimits(Limits{MaxPathLength: 8}))
+		assertLimit(t, err, "path length", 8)
+		assert.EqualError(t, err, "path length limit of 8 exceeded")
+
+		_, err = NewEvaluator("$.store", WithLimits(Limits{MaxPathLength: 8}))
+		assert.NoError(t, err)
+
+		_, err = Root().Child("store", "book").Evaluator(WithLimits(Limits{MaxPathLength: 8}))
+		assertLimit(t, err, "path length", 8)
+	})
+
+	t.Run("selectors", func(t *testing.T) {
+		_, err := NewEvaluator("$.a[?@.b && @.c.d]", WithLimits(Limits{MaxSelectors: 4}))
+		assertLimit(t, err, "selectors", 4)
+
+		_, err = NewEvaluator("$.a[?@.b && @.c]", WithLimits(Limits{MaxSelectors: 4}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("filter depth", func(t *testing.T) {
+		_, err := NewEvaluator("$[?@.a == 1 && !(@.b || @.c)]", WithLimits(Limits{MaxFilterDepth: 3}))
+		assertLimit(t, err, "filter depth", 3)
+
+		_, err = NewEvaluator("$[?@[?@[?@.a]]]", WithLimits(Limits{MaxFilterDepth: 2}))
+		assertLimit(t, err, "filter depth", 2)
+
+		_, err = NewEvaluator("$[?@.a == 1 && @.b]", WithLimits(Limits{MaxFilterDepth: 3}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("deeply nested path", func(t *testing.T) {
+		// The limit is checked while parsing, so the parser stops long before
+		// it would reach the end of these paths. The paths are not closed, so
+		// any other error would mean that they were parsed too deeply.
+		limits := WithLimits(Limits{MaxFilterDepth: 8})
+		paths := []string{
+			"$[?" + strings.Repeat("(", 100000),
+			"$[?" + strings.Repeat("!(", 100000),
+			"$" + strings.Repeat("[?@", 100000),
+		}
+
+		for _, path := range paths {
+			_, err := NewEvaluator(path, limits)
+			assertLimit(t, err, "filter depth", 8)
+
+			_, err = NewQuerySet(map[string]string{"a": path}, limits)
+			assertLimit(t, err, "filter depth", 8)
+		}
+	})
+
+	t.Run("input size", func(t *testing.T) {
+		limits := WithLimits(Limits{MaxInputSize: 10})
+		data := []byte(`{"a": [1, 2, 3]}`)
+
+		for _, path := range []string{"$.a", "$..a"} {
+			eval := MustCompile(path, limits)
+
+			_, err := eval.Evaluate(data)
+			assertLimit(t, err, "input size", 10)
+
+			_, err = eval.EvaluateRaw(data)
+			assertLimit(t, err, "input size", 10)
+
+			_, err = eval.Exists(data)
+			assertLimit(t, err, "input size", 10)
+		}
+	})
+
+	t.Run("depth", func(t *testing.T) {
+		data := []byte(`{"a": {"b": {"c": {"d": 1}}}}`)
+
+		_, err := MustCompile("$..d", WithLimits(Limits{MaxDepth: 3})).Evaluate(data)
+		assertLimit(t, err, "depth", 3)
+
+		// The first match is found before the values below it are reached.
+		result, err := MustCompile("$..d", WithLimits(Limits{MaxDepth: 3})).First(data)
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), result)
+
+		_, err = MustCompile("$..d", WithLimits(Limits{MaxDepth: 2})).First(data)
+		assertLimit(t, err, "depth", 2)
+
+		values, err := MustCompile("$.a.b..d", WithLimits(Limits{MaxDepth: 3})).Evaluate(data)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{float64(1)}, values)
+	})
+
+	t.Run("results", func(t *testing.T) {
+		limits := WithLimits(Limits{MaxResults: 3})
+		data := []byte(`{"a": [1, 2, 3, 4], "b": [1, 2]}`)
+
+		_, err := MustCompile("$.a[*]", limits).Evaluate(data)
+		assertLimit(t, err, "results", 3)
+
+		_, err = MustCompile("$..*", limits).Evaluate(data)
+		assertLimit(t, err, "results", 3)
+
+		_, err = MustCompile("$[?@[3]]", limits).Count(data)
+		assert.NoError(t, err)
+
+		_, err = MustCompile("$.*[?@ > 0]", limits).Count(data)
+		assertLimit(t, err, "results", 3)
+
+		err = MustCompile("$.a[*]", limits).Walk(data, func(string, interface{}) bool {
+			return true
+		})
+		assertLimit(t, err, "results", 3)
+
+		result, err := MustCompile("$.b[*]", limits).Evaluate(data)
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+	})
+}
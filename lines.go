@@ -0,0 +1,136 @@
+package jsonpath
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// LineResult is the result of evaluating a jsonpath against a single line
+	// of newline delimited json. Line is the number of the line starting at
+	// 1, Err is set if the line could not be evaluated.
+	LineResult struct {
+		Line    int
+		Results []interface{}
+		Err     error
+	}
+
+	// lineJob is a line that is waiting to be evaluated by a worker. The
+	// result is sent to the result channel.
+	lineJob struct {
+		line   int
+		data   []byte
+		result chan<- LineResult
+	}
+)
+
+// EvaluateLines will read newline delimited json (NDJSON) from the reader and
+// evaluate the jsonpath against each line, calling fn with the result of each
+// line in order. Lines that fail to evaluate are passed to fn with their
+// error instead of stopping the evaluation, and blank lines are skipped.
+//
+// If workers is more than 1 then that many lines are evaluated concurrently,
+// fn is still called from a single goroutine in the order of the lines.
+// Evaluation stops as soon as fn returns false, although the reader may
+// still be read until the end of the next line. An error is only returned if
+// the reader fails.
+func (e *Evaluator) EvaluateLines(r io.Reader, workers int, fn func(result LineResult) bool) error {
+	if workers <= 1 {
+		return readLines(r, func(line int, data []byte) bool {
+			return fn(e.evaluateLine(line, data))
+		})
+	}
+
+	jobs := make(chan lineJob)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.result <- e.evaluateLine(job.line, job.data)
+			}
+		}()
+	}
+
+	// Each line reserves its place in pending before it is evaluated, so that
+	// the results can be passed to fn in order.
+	pending := make(chan chan LineResult, workers)
+	done := make(chan struct{})
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+
+		readErr <- readLines(r, func(line int, data []byte) bool {
+			result := make(chan LineResult, 1)
+			select {
+			case pending <- result:
+			case <-done:
+				return false
+			}
+
+			select {
+			case jobs <- lineJob{line: line, data: data, result: result}:
+				return true
+			case <-done:
+				return false
+			}
+		})
+	}()
+
+	stopped := false
+	for result := range pending {
+		// Once stopped the remaining results might never be evaluated, so
+		// they are not waited for.
+		if stopped {
+			continue
+		}
+
+		if !fn(<-result) {
+			stopped = true
+			close(done)
+		}
+	}
+	wg.Wait()
+
+	return <-readErr
+}
+
+// evaluateLine will evaluate the jsonpath against a single line.
+func (e *Evaluator) evaluateLine(line int, data []byte) LineResult {
+	results, err := e.Evaluate(data)
+	if err != nil {
+		err = errors.Wrapf(err, "line %d", line)
+	}
+
+	return LineResult{
+		Line:    line,
+		Results: results,
+		Err:     err,
+	}
+}
+
+// readLines will call fn with the number and contents of each line that is
+// not blank until fn returns false.
+func readLines(r io.Reader, fn func(line int, data []byte) bool) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return errors.Wrapf(err, "failed to read line %d", line)
+		}
+
+		if len(bytes.TrimSpace(data)) > 0 && !fn(line, data) {
+			return nil
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
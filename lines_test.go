@@ -0,0 +1,93 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const LogsJson = `{"level": "info", "msg": "started"}
+{"level": "error", "msg": "failed", "code": 1}
+
+{"level": "info", "msg": 
+{"level": "error", "msg": "retry", "code": 2}
+{"level": "debug"}`
+
+func TestEvaluator_EvaluateLines(t *testing.T) {
+	collect := func(t *testing.T, path string, input string, workers int) []LineResult {
+		results := make([]LineResult, 0)
+		err := MustCompile(path).EvaluateLines(strings.NewReader(input), workers, func(result LineResult) bool {
+			results = append(results, result)
+			return true
+		})
+		require.NoError(t, err)
+
+		return results
+	}
+
+	for _, workers := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			results := collect(t, "$[?@.level == 'error'].code", "["+strings.ReplaceAll(LogsJson, "\n", "]\n[")+"]", workers)
+			require.Len(t, results, 6)
+
+			lines := make([]int, len(results))
+			for i, result := range results {
+				lines[i] = result.Line
+			}
+			assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, lines)
+
+			results = collect(t, "$.code", LogsJson, workers)
+			require.Len(t, results, 5)
+
+			assert.Equal(t, LineResult{Line: 1, Results: []interface{}{}}, results[0])
+			assert.Equal(t, LineResult{Line: 2, Results: []interface{}{float64(1)}}, results[1])
+			assert.Equal(t, 4, results[2].Line)
+			assert.Error(t, results[2].Err)
+			assert.Contains(t, results[2].Err.Error(), "line 4: ")
+			assert.Equal(t, LineResult{Line: 5, Results: []interface{}{float64(2)}}, results[3])
+			assert.Equal(t, LineResult{Line: 6, Results: []interface{}{}}, results[4])
+		})
+	}
+
+	t.Run("many lines in order", func(t *testing.T) {
+		lines := make([]string, 1000)
+		for i := range lines {
+			lines[i] = fmt.Sprintf(`{"n": %d}`, i)
+		}
+
+		results := collect(t, "$.n", strings.Join(lines, "\r\n")+"\n", 8)
+		require.Len(t, results, 1000)
+		for i, result := range results {
+			require.NoError(t, result.Err)
+			assert.Equal(t, i+1, result.Line)
+			assert.Equal(t, []interface{}{float64(i)}, result.Results)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		lines := strings.Repeat("{\"a\": 1}\n", 100)
+		for _, workers := range []int{1, 4} {
+			count := 0
+			err := MustCompile("$.a").EvaluateLines(strings.NewReader(lines), workers, func(result LineResult) bool {
+				count++
+				return count < 3
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 3, count)
+		}
+	})
+
+	t.Run("reader error", func(t *testing.T) {
+		for _, workers := range []int{1, 4} {
+			reader := iotest.TimeoutReader(strings.NewReader(strings.Repeat("{\"a\": 1}\n", 1000)))
+			err := MustCompile("$.a").EvaluateLines(reader, workers, func(result LineResult) bool {
+				return true
+			})
+			assert.Error(t, err)
+		}
+	})
+}
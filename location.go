@@ -0,0 +1,81 @@
+package jsonpath
+
+import (
+	"strconv"
+	"strings"
+)
+
+type (
+	// location is the position of a node within a document. Each location
+	// points to the location of its parent, the root of the document is a
+	// location without a parent. The key of a location is either the string
+	// name of an object member or the int index of an array element.
+	//
+	// Locations are only tracked when the evaluation starts with a root
+	// location, otherwise every location will be nil.
+	location struct {
+		parent *location
+		key    interface{}
+	}
+
+	// locatedNode is a node that was selected during an evaluation along with
+	// where it is within the document.
+	locatedNode struct {
+		value    jsonNode
+		location *location
+	}
+)
+
+// rootLocation returns the location of the root of a document.
+func rootLocation() *location {
+	return &location{}
+}
+
+// child returns the location of a member or element within this location. If
+// locations are not being tracked then nil is returned.
+func (l *location) child(key interface{}) *location {
+	if l == nil {
+		return nil
+	}
+
+	return &location{
+		parent: l,
+		key:    key,
+	}
+}
+
+// keys returns each of the keys from the root of the document down to this
+// location.
+func (l *location) keys() []interface{} {
+	depth := 0
+	for current := l; current != nil && current.parent != nil; current = current.parent {
+		depth++
+	}
+
+	keys := make([]interface{}, depth)
+	for current := l; current != nil && current.parent != nil; current = current.parent {
+		depth--
+		keys[depth] = current.key
+	}
+
+	return keys
+}
+
+// String returns the normalized path of the location, for example
+// `$['store']['book'][0]`.
+func (l *location) String() string {
+	var builder strings.Builder
+	builder.WriteByte('$')
+	for _, key := range l.keys() {
+		builder.WriteByte('[')
+		switch k := key.(type) {
+		case string:
+			builder.WriteString(quoteString(k))
+		case int:
+			builder.WriteString(strconv.Itoa(k))
+		}
+		builder.WriteByte(']')
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,25 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocation_String(t *testing.T) {
+	t.Run("root", func(t *testing.T) {
+		assert.Equal(t, "$", rootLocation().String())
+	})
+
+	t.Run("members and elements", func(t *testing.T) {
+		location := rootLocation().child("store").child("book").child(0).child("it's")
+		assert.Equal(t, `$['store']['book'][0]['it\'s']`, location.String())
+		assert.Equal(t, []interface{}{"store", "book", 0, "it's"}, location.keys())
+	})
+
+	t.Run("not tracked", func(t *testing.T) {
+		var location *location
+		assert.Nil(t, location.child("store"))
+		assert.Empty(t, location.keys())
+	})
+}
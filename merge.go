@@ -0,0 +1,110 @@
+package jsonpath
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// MergePatch will apply the JSON Merge Patch (RFC 7386) to every value that
+// the jsonpath matches in the provided json and return the updated json. For
+// example merging `{"enabled": false}` with `$.features[?@.beta == true]`
+// will disable every beta feature. Numbers are kept exactly as they were
+// written, but the members of objects are written in order of their keys.
+// Merge patches can only be applied to json documents.
+func (e *Evaluator) MergePatch(data, patch []byte) ([]byte, error) {
+	if err := e.checkLocations(); err != nil {
+		return nil, err
+	}
+
+	if err := e.checkJSONInput("merge patches"); err != nil {
+		return nil, err
+	}
+
+	merge, err := decodeJson(patch, JSONNumbers)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid merge patch")
+	}
+
+	if err := e.limits.checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	// The json is decoded with json.Number so that numbers that are not
+	// changed are written back exactly.
+	document, err := decodeJson(data, JSONNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := e.run(locatedNode{value: document, location: rootLocation()}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]interface{}, len(nodes))
+	for i, node := range nodes {
+		keys[i] = node.location.keys()
+	}
+
+	// Descendants are merged before the values that contain them, so that
+	// merging a value cannot remove a descendant that has not been merged.
+	sort.Slice(keys, func(i, j int) bool {
+		return compareKeys(keys[i], keys[j]) > 0
+	})
+
+	for i, key := range keys {
+		if i > 0 && compareKeys(keys[i-1], key) == 0 {
+			continue
+		}
+
+		path := make([]string, len(key))
+		for j, k := range key {
+			switch k := k.(type) {
+			case string:
+				path[j] = k
+			case int:
+				path[j] = strconv.Itoa(k)
+			}
+		}
+
+		target, err := getValue(document, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if document, err = replaceValue(document, path, mergePatch(target, merge)); err != nil {
+			return nil, err
+		}
+	}
+
+	return encodeJson(document)
+}
+
+// mergePatch will merge the patch into the target as defined by RFC 7386 and
+// return the result. Objects within the target are changed in place.
+func mergePatch(target, patch jsonNode) jsonNode {
+	patchObject, ok := patch.(jsonObject)
+	if !ok {
+		// The patch is copied so that the same values are not shared by
+		// each of the targets.
+		return copyValue(patch)
+	}
+
+	targetObject, ok := target.(jsonObject)
+	if !ok {
+		targetObject = jsonObject{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
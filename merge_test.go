@@ -0,0 +1,109 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_MergePatch(t *testing.T) {
+	const features = `{
+		"features": [
+			{"name": "a", "beta": true, "enabled": true, "rollout": {"percent": 10, "regions": ["us"]}},
+			{"name": "b", "beta": false, "enabled": true},
+			{"name": "c", "beta": true, "id": 1234567890123456789}
+		]
+	}`
+
+	merge := func(t *testing.T, path, patch string) string {
+		result, err := MustCompile(path).MergePatch([]byte(features), []byte(patch))
+		require.NoError(t, err)
+
+		return string(result)
+	}
+
+	t.Run("filtered objects", func(t *testing.T) {
+		result := merge(t, "$.features[?(@.beta == true)]", `{"enabled": false}`)
+		assert.JSONEq(t, `{
+			"features": [
+				{"name": "a", "beta": true, "enabled": false, "rollout": {"percent": 10, "regions": ["us"]}},
+				{"name": "b", "beta": false, "enabled": true},
+				{"name": "c", "beta": true, "enabled": false, "id": 1234567890123456789}
+			]
+		}`, result)
+		assert.Contains(t, result, "1234567890123456789")
+	})
+
+	t.Run("nested and removed members", func(t *testing.T) {
+		result := merge(t, "$.features[0]", `{"beta": null, "rollout": {"percent": 50, "regions": null}}`)
+		assert.JSONEq(t, `{
+			"features": [
+				{"name": "a", "enabled": true, "rollout": {"percent": 50}},
+				{"name": "b", "beta": false, "enabled": true},
+				{"name": "c", "beta": true, "id": 1234567890123456789}
+			]
+		}`, result)
+	})
+
+	t.Run("values are not shared", func(t *testing.T) {
+		result, err := MustCompile("$.features[*]").MergePatch([]byte(features), []byte(`{"tags": {"team": "x"}}`))
+		require.NoError(t, err)
+
+		result, err = MustCompile("$.features[0].tags").MergePatch(result, []byte(`{"team": "y"}`))
+		require.NoError(t, err)
+
+		teams, err := MustCompile("$.features[*].tags.team").Evaluate(result)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"y", "x", "x"}, teams)
+	})
+
+	t.Run("non object targets", func(t *testing.T) {
+		result := merge(t, "$.features[*].name", `{"first": "x"}`)
+		names, err := MustCompile("$.features[*].name.first").Evaluate([]byte(result))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"x", "x", "x"}, names)
+
+		result = merge(t, "$.features[1].enabled", `[1, 2]`)
+		enabled, err := MustCompile("$.features[1].enabled").Evaluate([]byte(result))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{[]interface{}{float64(1), float64(2)}}, enabled)
+	})
+
+	t.Run("parent and child", func(t *testing.T) {
+		result := merge(t, "$..rollout", `{"percent": 100}`)
+		assert.Contains(t, result, `"rollout":{"percent":100,"regions":["us"]}`)
+
+		result = merge(t, "$.features[0]['rollout','rollout']", `{"percent": 100}`)
+		assert.Contains(t, result, `"rollout":{"percent":100,"regions":["us"]}`)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		result := merge(t, "$", `{"features": null, "version": 2}`)
+		assert.JSONEq(t, `{"version": 2}`, result)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		result := merge(t, "$.missing", `{"a": 1}`)
+		assert.JSONEq(t, features, result)
+	})
+
+	t.Run("html characters", func(t *testing.T) {
+		result, err := MustCompile("$.b").MergePatch([]byte(`{"a": "<x>&", "b": {}}`), []byte(`{"c": "<y>"}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"<x>&","b":{"c":"<y>"}}`, string(result))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := MustCompile("$").MergePatch([]byte(features), []byte(`{"a":`))
+		assert.Error(t, err)
+
+		_, err = MustCompile("$").MergePatch([]byte(`{"a":`), []byte(`{}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("decoder", func(t *testing.T) {
+		_, err := MustCompile("$", WithDecoder(YAMLDecoder)).MergePatch([]byte("a: 1"), []byte(`{"b": 2}`))
+		assert.EqualError(t, err, "merge patches are only supported for json documents")
+	})
+}
@@ -0,0 +1,234 @@
+package jsonpath
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// msgpackTimestamp is the extension type of MessagePack timestamps.
+const msgpackTimestamp = -1
+
+// MessagePackDecoder is a Decoder for MessagePack documents, so that jsonpaths
+// can be evaluated against MessagePack without converting it to json. Maps
+// are decoded as objects, with integer and binary keys converted to strings.
+// Binary data is decoded as a []byte, timestamps are decoded as RFC 3339
+// strings and any other extension is decoded as a []byte of its data.
+var MessagePackDecoder Decoder = msgpackDecoder{}
+
+type msgpackDecoder struct{}
+
+func (msgpackDecoder) Decode(data []byte, numbers NumberMode) (interface{}, error) {
+	reader := &msgpackReader{
+		binaryReader: binaryReader{data: data},
+	}
+
+	value, err := reader.item(numbers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode msgpack input")
+	}
+
+	if err := reader.finish(); err != nil {
+		return nil, errors.Wrap(err, "failed to decode msgpack input")
+	}
+
+	return value, nil
+}
+
+type msgpackReader struct {
+	binaryReader
+}
+
+// item will read the next item, decoding numbers using the provided mode.
+func (r *msgpackReader) item(numbers NumberMode) (jsonNode, error) {
+	start := r.offset
+	format, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case format <= 0x7f:
+		return int64Node(int64(format), numbers), nil
+	case format >= 0xe0:
+		return int64Node(int64(int8(format)), numbers), nil
+	case format <= 0x8f:
+		return r.object(uint64(format&0x0f), numbers)
+	case format <= 0x9f:
+		return r.array(uint64(format&0x0f), numbers)
+	case format <= 0xbf:
+		return r.text(uint64(format & 0x1f))
+	}
+
+	switch format {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		length, err := r.readUint(1 << (format - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+
+		return r.read(length)
+	case 0xc7, 0xc8, 0xc9:
+		length, err := r.readUint(1 << (format - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+
+		return r.extension(length)
+	case 0xca:
+		bits, err := r.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+
+		return float64Node(float64(math.Float32frombits(uint32(bits))), numbers), nil
+	case 0xcb:
+		bits, err := r.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+
+		return float64Node(math.Float64frombits(bits), numbers), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		value, err := r.readUint(1 << (format - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+
+		return uint64Node(value, numbers), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (format - 0xd0)
+		value, err := r.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+
+		// Sign extend the value from its size to 64 bits.
+		shift := 64 - 8*size
+		return int64Node(int64(value<<shift)>>shift, numbers), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.extension(1 << (format - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		length, err := r.readUint(1 << (format - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+
+		return r.text(length)
+	case 0xdc, 0xdd:
+		length, err := r.readUint(2 << (format - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+
+		return r.array(length, numbers)
+	case 0xde, 0xdf:
+		length, err := r.readUint(2 << (format - 0xde))
+		if err != nil {
+			return nil, err
+		}
+
+		return r.object(length, numbers)
+	default:
+		return nil, errors.Errorf("invalid format 0x%x at offset %d", format, start)
+	}
+}
+
+func (r *msgpackReader) text(length uint64) (jsonNode, error) {
+	text, err := r.read(length)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(text), nil
+}
+
+func (r *msgpackReader) array(length uint64, numbers NumberMode) (jsonNode, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	array := make(jsonArray, 0, r.capacity(length))
+	for i := uint64(0); i < length; i++ {
+		item, err := r.item(numbers)
+		if err != nil {
+			return nil, err
+		}
+
+		array = append(array, item)
+	}
+
+	return array, nil
+}
+
+func (r *msgpackReader) object(length uint64, numbers NumberMode) (jsonNode, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	object := make(jsonObject, r.capacity(length))
+	for i := uint64(0); i < length; i++ {
+		// Keys are decoded as json numbers so that they are formatted
+		// exactly.
+		start := r.offset
+		key, err := r.item(JSONNumbers)
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := binaryKey(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key at offset %d", start)
+		}
+
+		if object[name], err = r.item(numbers); err != nil {
+			return nil, err
+		}
+	}
+
+	return object, nil
+}
+
+// extension will read an extension with data of the provided length.
+// Timestamps are formatted as RFC 3339 strings, the data of any other
+// extension is returned as is.
+func (r *msgpackReader) extension(length uint64) (jsonNode, error) {
+	start := r.offset
+	extension, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := r.read(length)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(extension) != msgpackTimestamp {
+		return data, nil
+	}
+
+	var seconds, nanoseconds uint64
+	switch length {
+	case 4:
+		seconds = bigEndian(data)
+	case 8:
+		value := bigEndian(data)
+		seconds, nanoseconds = value&(1<<34-1), value>>34
+	case 12:
+		seconds, nanoseconds = bigEndian(data[4:]), bigEndian(data[:4])
+	default:
+		return nil, errors.Errorf("invalid timestamp of %d bytes at offset %d", length, start)
+	}
+
+	return time.Unix(int64(seconds), int64(nanoseconds)).UTC().Format(time.RFC3339Nano), nil
+}
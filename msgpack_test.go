@@ -0,0 +1,76 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// UsersMsgpack is {"users": [{"name": "a", "age": 30}]}.
+const UsersMsgpack = "81a575736572739182a46e616d65a161a36167651e"
+
+func TestMessagePackDecoder(t *testing.T) {
+	t.Run("evaluate", func(t *testing.T) {
+		eval, err := NewEvaluator("$.users[?@.age >= 18].name", WithDecoder(MessagePackDecoder))
+		require.NoError(t, err)
+
+		result, err := eval.Evaluate(mustHex(t, UsersMsgpack))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"a"}, result)
+	})
+
+	examples := []struct {
+		data     string
+		numbers  NumberMode
+		expected interface{}
+	}{
+		{"93 01 a161 c3", Float64Numbers, []interface{}{float64(1), "a", true}},
+		{"cd 03e8", Float64Numbers, float64(1000)},
+		{"d1 fc18", Float64Numbers, float64(-1000)},
+		{"d3 ffffffffffffffff", JSONNumbers, json.Number("-1")},
+		{"e0", JSONNumbers, json.Number("-32")},
+		{"cf ffffffffffffffff", JSONNumbers, json.Number("18446744073709551615")},
+		{"cb 3ff8000000000000", Float64Numbers, 1.5},
+		{"ca 3fc00000", JSONNumbers, json.Number("1.5")},
+		{"c2", Float64Numbers, false},
+		{"c0", Float64Numbers, nil},
+		{"c4 03 010203", Float64Numbers, []byte{1, 2, 3}},
+		{"d9 03 616263", Float64Numbers, "abc"},
+		{"dc 0002 01 02", Float64Numbers, []interface{}{float64(1), float64(2)}},
+		{"82 01 02 a162 c0", Float64Numbers, map[string]interface{}{"1": float64(2), "b": nil}},
+		{"d6 ff 00000001", Float64Numbers, "1970-01-01T00:00:01Z"},
+		{"d7 ff 00000004 00000001", Float64Numbers, "1970-01-01T00:00:01.000000001Z"},
+		{"d4 01 07", Float64Numbers, []byte{7}},
+	}
+	for _, example := range examples {
+		t.Run(example.data, func(t *testing.T) {
+			value, err := MessagePackDecoder.Decode(mustHex(t, example.data), example.numbers)
+			require.NoError(t, err)
+			assert.Equal(t, example.expected, value)
+		})
+	}
+
+	invalid := map[string]string{
+		"empty":         "",
+		"never used":    "c1",
+		"truncated":     "92 01",
+		"trailing data": "01 02",
+		"array key":     "81 90 01",
+		"bad timestamp": "d5 ff 0001",
+		"too long":      "c6 ffffffff 00",
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := MessagePackDecoder.Decode(mustHex(t, data), Float64Numbers)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("too deep", func(t *testing.T) {
+		_, err := MessagePackDecoder.Decode(mustHex(t, strings.Repeat("91", maxBinaryDepth+1)+"00"), Float64Numbers)
+		assert.Error(t, err)
+	})
+}
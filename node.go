@@ -0,0 +1,206 @@
+package jsonpath
+
+import (
+	"sort"
+)
+
+// Kind is the kind of value that a Node holds.
+type Kind int
+
+const (
+	// ScalarKind is a string, number, boolean or null.
+	ScalarKind Kind = iota
+	// ArrayKind is an ordered list of values.
+	ArrayKind
+	// ObjectKind is a set of named values.
+	ObjectKind
+)
+
+// Node is a value in a document that has not been decoded into the maps and
+// slices that json is decoded into, such as an arena backed tree or a row of a
+// database. Nodes can be evaluated with Evaluator.EvaluateNode without
+// converting them first.
+//
+// The values that a Node returns from Field and Index can be Nodes themselves
+// or the values that json is decoded into, so a tree can mix both.
+type Node interface {
+	// Kind returns what kind of value the node holds.
+	Kind() Kind
+
+	// Len returns the number of elements of an array or the number of members
+	// of an object.
+	Len() int
+
+	// Keys returns the names of the members of an object. The order does not
+	// matter, members are always visited in order of their names.
+	Keys() []string
+
+	// Field returns the value of the member of an object with the provided
+	// name, or false if the object has no such member.
+	Field(name string) (interface{}, bool)
+
+	// Index returns the element of an array at the provided index, which is
+	// always at least 0 and less than Len.
+	Index(index int) interface{}
+
+	// Value returns the value of a scalar. It must be a string, a bool, nil or
+	// a number of one of the types of the NumberMode of the Evaluator.
+	Value() interface{}
+}
+
+// EvaluateNode will run the compiled jsonpath against the provided document
+// instead of parsing json. The results are the values from the document as
+// they are, which may be Nodes.
+func (e *Evaluator) EvaluateNode(root Node) ([]interface{}, error) {
+	nodes, err := e.run(locatedNode{value: root}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return valuesOf(nodes), nil
+}
+
+// arrayLength returns the length of the value if it is an array.
+func arrayLength(data jsonNode) (int, bool) {
+	switch array := data.(type) {
+	case jsonArray:
+		return len(array), true
+	case Node:
+		if array.Kind() == ArrayKind {
+			return array.Len(), true
+		}
+	}
+
+	return 0, false
+}
+
+// elementAt returns the element of an array, the index must be within the
+// bounds of the array.
+func elementAt(data jsonNode, index int) jsonNode {
+	if array, ok := data.(jsonArray); ok {
+		return array[index]
+	}
+
+	return data.(Node).Index(index)
+}
+
+// memberOf returns the member of the value with the provided name if the
+// value is an object that has that member.
+func memberOf(data jsonNode, name string) (jsonNode, bool) {
+	switch object := data.(type) {
+	case jsonObject:
+		member, ok := object[name]
+		return member, ok
+	case Node:
+		if object.Kind() == ObjectKind {
+			return object.Field(name)
+		}
+	}
+
+	return nil, false
+}
+
+// objectKeys returns the names of the members of the value in order if it is
+// an object.
+func objectKeys(data jsonNode) ([]string, bool) {
+	switch object := data.(type) {
+	case jsonObject:
+		return sortedKeys(object), true
+	case Node:
+		if object.Kind() == ObjectKind {
+			// The keys are copied so that the node's own keys are not
+			// reordered.
+			keys := append([]string(nil), object.Keys()...)
+			sort.Strings(keys)
+
+			return keys, true
+		}
+	}
+
+	return nil, false
+}
+
+// scalarOf returns the value of a scalar Node, any other value is returned as
+// is.
+func scalarOf(data jsonNode) jsonNode {
+	if node, ok := data.(Node); ok && node.Kind() == ScalarKind {
+		return node.Value()
+	}
+
+	return data
+}
+
+// forEachChild will call fn with each element of an array or each member of
+// an object in order of their names, any other value has no children.
+// Iterating stops as soon as fn returns false or an error, in which case the
+// result of fn is returned.
+func forEachChild(node locatedNode, fn func(child locatedNode) (bool, error)) (bool, error) {
+	if length, ok := arrayLength(node.value); ok {
+		for i := 0; i < length; i++ {
+			ok, err := fn(locatedNode{
+				value:    elementAt(node.value, i),
+				location: node.location.child(i),
+			})
+			if !ok || err != nil {
+				return ok, err
+			}
+		}
+
+		return true, nil
+	}
+
+	keys, _ := objectKeys(node.value)
+	for _, key := range keys {
+		member, _ := memberOf(node.value, key)
+		ok, err := fn(locatedNode{
+			value:    member,
+			location: node.location.child(key),
+		})
+		if !ok || err != nil {
+			return ok, err
+		}
+	}
+
+	return true, nil
+}
+
+// nodesEqual is valuesEqual for when either of the values is a Node.
+func nodesEqual(left, right jsonNode) bool {
+	left, right = scalarOf(left), scalarOf(right)
+	_, leftNode := left.(Node)
+	_, rightNode := right.(Node)
+	if !leftNode && !rightNode {
+		return valuesEqual(left, right)
+	}
+
+	if length, ok := arrayLength(left); ok {
+		otherLength, ok := arrayLength(right)
+		if !ok || length != otherLength {
+			return false
+		}
+
+		for i := 0; i < length; i++ {
+			if !valuesEqual(elementAt(left, i), elementAt(right, i)) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	keys, ok := objectKeys(left)
+	otherKeys, otherOk := objectKeys(right)
+	if !ok || !otherOk || len(keys) != len(otherKeys) {
+		return false
+	}
+
+	for _, key := range keys {
+		value, _ := memberOf(left, key)
+		other, ok := memberOf(right, key)
+		if !ok || !valuesEqual(value, other) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	// tableNode is a Node for the rows of a database table.
+	tableNode struct {
+		columns []string
+		rows    [][]interface{}
+	}
+
+	rowNode struct {
+		columns []string
+		values  []interface{}
+	}
+
+	scalarNode struct {
+		value interface{}
+	}
+)
+
+func (t tableNode) Kind() Kind                        { return ArrayKind }
+func (t tableNode) Len() int                          { return len(t.rows) }
+func (t tableNode) Keys() []string                    { return nil }
+func (t tableNode) Field(string) (interface{}, bool)  { return nil, false }
+func (t tableNode) Index(index int) interface{}       { return rowNode{t.columns, t.rows[index]} }
+func (t tableNode) Value() interface{}                { return nil }
+func (r rowNode) Kind() Kind                          { return ObjectKind }
+func (r rowNode) Len() int                            { return len(r.columns) }
+func (r rowNode) Keys() []string                      { return r.columns }
+func (r rowNode) Index(int) interface{}               { return nil }
+func (r rowNode) Value() interface{}                  { return nil }
+func (s scalarNode) Kind() Kind                       { return ScalarKind }
+func (s scalarNode) Len() int                         { return 0 }
+func (s scalarNode) Keys() []string                   { return nil }
+func (s scalarNode) Field(string) (interface{}, bool) { return nil, false }
+func (s scalarNode) Index(int) interface{}            { return nil }
+func (s scalarNode) Value() interface{}               { return s.value }
+
+func (r rowNode) Field(name string) (interface{}, bool) {
+	for i, column := range r.columns {
+		if column == name {
+			return r.values[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func TestEvaluator_EvaluateNode(t *testing.T) {
+	users := tableNode{
+		columns: []string{"name", "age", "tags"},
+		rows: [][]interface{}{
+			{"alice", scalarNode{float64(31)}, []interface{}{"admin"}},
+			{"bob", scalarNode{float64(25)}, []interface{}{}},
+			{"carol", scalarNode{float64(42)}, []interface{}{"admin", "ops"}},
+		},
+	}
+
+	evaluate := func(t *testing.T, path string) []interface{} {
+		result, err := MustCompile(path).EvaluateNode(users)
+		require.NoError(t, err, path)
+
+		return result
+	}
+
+	assert.Equal(t, []interface{}{"alice", "bob", "carol"}, evaluate(t, "$[*].name"))
+	assert.Equal(t, []interface{}{"alice", "carol"}, evaluate(t, "$[?@.age > 30].name"))
+	assert.Equal(t, []interface{}{"carol"}, evaluate(t, "$[?@.tags[1] == 'ops'].name"))
+	assert.Equal(t, []interface{}{"carol"}, evaluate(t, "$[-1].name"))
+	assert.Equal(t, []interface{}{"bob", "carol"}, evaluate(t, "$[1:].name"))
+	assert.Equal(t, []interface{}{"alice", "bob"}, evaluate(t, "$[0, 1]['name']"))
+	assert.Equal(t, []interface{}{"admin", "admin", "ops"}, evaluate(t, "$..tags[*]"))
+	assert.Equal(t, []interface{}{"alice", "carol"}, evaluate(t, "$[?@.tags == $[0].tags || @.age == 42].name"))
+	assert.Equal(t, []interface{}{scalarNode{float64(25)}}, evaluate(t, "$[1].age"))
+	assert.Len(t, evaluate(t, "$..*"), 15)
+
+	t.Run("not an array", func(t *testing.T) {
+		result, err := MustCompile("$[0]").EvaluateNode(rowNode{})
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+}
+
+func Test_nodesEqual(t *testing.T) {
+	row := rowNode{
+		columns: []string{"a", "b"},
+		values:  []interface{}{scalarNode{float64(1)}, []interface{}{"x"}},
+	}
+
+	assert.True(t, valuesEqual(row, map[string]interface{}{"a": float64(1), "b": []interface{}{"x"}}))
+	assert.True(t, valuesEqual(map[string]interface{}{"a": float64(1), "b": []interface{}{"x"}}, row))
+	assert.False(t, valuesEqual(row, map[string]interface{}{"a": float64(2), "b": []interface{}{"x"}}))
+	assert.False(t, valuesEqual(row, map[string]interface{}{"a": float64(1)}))
+	assert.True(t, valuesEqual(scalarNode{"x"}, "x"))
+	assert.False(t, valuesEqual(scalarNode{"x"}, []interface{}{"x"}))
+}
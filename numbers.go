@@ -0,0 +1,289 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NumberMode is how numbers in the json are decoded by an Evaluator.
+type NumberMode int
+
+const (
+	// Float64Numbers will decode every number as a float64, the same as
+	// json.Unmarshal. Integers larger than 2^53 may lose precision.
+	Float64Numbers NumberMode = iota
+
+	// JSONNumbers will decode every number as a json.Number, which keeps the
+	// number exactly as it was written in the json.
+	JSONNumbers
+
+	// BigNumbers will decode integers as a *big.Int and any other number as a
+	// *big.Float so that they can be used without losing precision.
+	BigNumbers
+)
+
+// bigFloatPrecision is the precision in bits of the big.Floats that are used
+// for numbers that are not integers.
+const bigFloatPrecision = 256
+
+// Option changes how an Evaluator evaluates its jsonpath.
+type Option func(e *Evaluator)
+
+// WithNumberMode will change how the Evaluator decodes numbers. This affects
+// the results that are returned as well as how numbers are compared within
+// filters. By default numbers are decoded as float64.
+func WithNumberMode(mode NumberMode) Option {
+	return func(e *Evaluator) {
+		e.numbers = mode
+	}
+}
+
+// decodeJson will parse the json, decoding numbers using the provided mode.
+func decodeJson(input []byte, mode NumberMode) (jsonNode, error) {
+	if mode == Float64Numbers || !json.Valid(input) {
+		// Invalid json is always described by json.Unmarshal so that the error
+		// is the same regardless of the mode.
+		return parseJson(input)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+
+	var data jsonNode
+	if err := decoder.Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal input")
+	}
+
+	if mode == BigNumbers {
+		return toBigNumbers(data)
+	}
+
+	return data, nil
+}
+
+// toBigNumbers will replace every json.Number within the node with a big
+// number. Arrays and objects are changed in place.
+func toBigNumbers(node jsonNode) (jsonNode, error) {
+	var err error
+	switch data := node.(type) {
+	case json.Number:
+		return parseBigNumber(string(data))
+	case jsonArray:
+		for i, item := range data {
+			if data[i], err = toBigNumbers(item); err != nil {
+				return nil, err
+			}
+		}
+	case jsonObject:
+		for key, item := range data {
+			if data[key], err = toBigNumbers(item); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return node, nil
+}
+
+// parseBigNumber will parse a json number as a *big.Int if it is an integer,
+// or as a *big.Float otherwise.
+func parseBigNumber(number string) (jsonNode, error) {
+	if !strings.ContainsAny(number, ".eE") {
+		integer, ok := new(big.Int).SetString(number, 10)
+		if !ok {
+			return nil, errors.Errorf("failed to parse '%s' as integer", number)
+		}
+
+		return integer, nil
+	}
+
+	float, _, err := big.ParseFloat(number, 10, bigFloatPrecision, big.ToNearestEven)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse '%s' as float", number)
+	}
+
+	return float, nil
+}
+
+// int64Node returns the integer as a number in the provided mode, for decoders
+// of formats that have integers.
+func int64Node(value int64, mode NumberMode) jsonNode {
+	switch mode {
+	case JSONNumbers:
+		return json.Number(strconv.FormatInt(value, 10))
+	case BigNumbers:
+		return big.NewInt(value)
+	default:
+		return float64(value)
+	}
+}
+
+// uint64Node returns the unsigned integer as a number in the provided mode.
+func uint64Node(value uint64, mode NumberMode) jsonNode {
+	switch mode {
+	case JSONNumbers:
+		return json.Number(strconv.FormatUint(value, 10))
+	case BigNumbers:
+		return new(big.Int).SetUint64(value)
+	default:
+		return float64(value)
+	}
+}
+
+// bigIntNode returns an integer that does not fit in 64 bits as a number in
+// the provided mode.
+func bigIntNode(value *big.Int, mode NumberMode) jsonNode {
+	switch mode {
+	case JSONNumbers:
+		return json.Number(value.String())
+	case BigNumbers:
+		return value
+	default:
+		float, _ := new(big.Float).SetInt(value).Float64()
+		return float
+	}
+}
+
+// float64Node returns the float as a number in the provided mode. Infinity and
+// NaN are always a float64 since they cannot be written in json.
+func float64Node(value float64, mode NumberMode) jsonNode {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return value
+	}
+
+	switch mode {
+	case JSONNumbers:
+		return json.Number(strconv.FormatFloat(value, 'g', -1, 64))
+	case BigNumbers:
+		return new(big.Float).SetPrec(bigFloatPrecision).SetFloat64(value)
+	default:
+		return value
+	}
+}
+
+// compareNumbers will return -1, 0 or 1 if left is less than, equal to or
+// greater than right. If either of the values is not a number then false is
+// returned. When either number is a float64 they are both compared as
+// float64, otherwise they are compared exactly.
+func compareNumbers(left, right jsonNode) (int, bool) {
+	_, leftFloat := left.(float64)
+	_, rightFloat := right.(float64)
+	if leftFloat || rightFloat {
+		l, leftOk := float64Of(left)
+		r, rightOk := float64Of(right)
+		switch {
+		case !leftOk || !rightOk:
+			return 0, false
+		case l < r:
+			return -1, true
+		case l > r:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	l, leftOk := bigFloatOf(left)
+	r, rightOk := bigFloatOf(right)
+	if !leftOk || !rightOk {
+		return 0, false
+	}
+
+	return l.Cmp(r), true
+}
+
+// float64Of returns the value of a number as a float64.
+func float64Of(number jsonNode) (float64, bool) {
+	switch n := number.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		// Numbers that are out of range are still returned as infinity.
+		value, err := n.Float64()
+		return value, err == nil || value != 0
+	case *big.Int:
+		value, _ := new(big.Float).SetInt(n).Float64()
+		return value, true
+	case *big.Float:
+		value, _ := n.Float64()
+		return value, true
+	default:
+		return 0, false
+	}
+}
+
+// bigFloatOf returns the exact value of a number as a big.Float.
+func bigFloatOf(number jsonNode) (*big.Float, bool) {
+	switch n := number.(type) {
+	case int64:
+		return new(big.Float).SetInt64(n), true
+	case json.Number:
+		value, err := parseBigNumber(string(n))
+		if err != nil {
+			return nil, false
+		}
+
+		return bigFloatOf(value)
+	case *big.Int:
+		return new(big.Float).SetInt(n), true
+	case *big.Float:
+		return n, true
+	default:
+		return nil, false
+	}
+}
+
+// valuesEqual will return true if both values are the same. Numbers are equal
+// if they have the same value, regardless of how they were decoded.
+func valuesEqual(left, right jsonNode) bool {
+	_, leftNode := left.(Node)
+	_, rightNode := right.(Node)
+	if leftNode || rightNode {
+		return nodesEqual(left, right)
+	}
+
+	if comparison, ok := compareNumbers(left, right); ok {
+		return comparison == 0
+	}
+
+	switch l := left.(type) {
+	case jsonArray:
+		r, ok := right.(jsonArray)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+
+		for i := range l {
+			if !valuesEqual(l[i], r[i]) {
+				return false
+			}
+		}
+
+		return true
+	case jsonObject:
+		r, ok := right.(jsonObject)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+
+		for key, value := range l {
+			other, ok := r[key]
+			if !ok || !valuesEqual(value, other) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return reflect.DeepEqual(left, right)
+	}
+}
@@ -0,0 +1,153 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const OrdersJson = `{
+	"orders": [
+		{"id": 1234567890123456789, "total": 10.10},
+		{"id": 1234567890123456790, "total": 0.30},
+		{"id": 98765432109876543210, "total": 1e2}
+	]
+}`
+
+func TestWithNumberMode(t *testing.T) {
+	evaluate := func(t *testing.T, path string, mode NumberMode) []interface{} {
+		eval, err := NewEvaluator(path, WithNumberMode(mode))
+		require.NoError(t, err, path)
+
+		result, err := eval.Evaluate([]byte(OrdersJson))
+		require.NoError(t, err, path)
+
+		return result
+	}
+
+	t.Run("float64 by default", func(t *testing.T) {
+		result := evaluate(t, "$.orders[0].id", Float64Numbers)
+		assert.Equal(t, []interface{}{float64(1234567890123456789)}, result)
+	})
+
+	t.Run("json numbers", func(t *testing.T) {
+		result := evaluate(t, "$.orders[*].id", JSONNumbers)
+		assert.Equal(t, []interface{}{
+			json.Number("1234567890123456789"),
+			json.Number("1234567890123456790"),
+			json.Number("98765432109876543210"),
+		}, result)
+	})
+
+	t.Run("big numbers", func(t *testing.T) {
+		result := evaluate(t, "$.orders[2]", BigNumbers)
+		require.Len(t, result, 1)
+
+		order := result[0].(map[string]interface{})
+		id, ok := new(big.Int).SetString("98765432109876543210", 10)
+		require.True(t, ok)
+		assert.Equal(t, 0, id.Cmp(order["id"].(*big.Int)))
+		assert.Equal(t, "100", order["total"].(*big.Float).Text('f', -1))
+	})
+
+	t.Run("exact filter comparisons", func(t *testing.T) {
+		for _, mode := range []NumberMode{JSONNumbers, BigNumbers} {
+			result := evaluate(t, "$.orders[?@.id == 1234567890123456789].total", mode)
+			assert.Len(t, result, 1, "mode %d", mode)
+
+			result = evaluate(t, "$.orders[?@.id > 1234567890123456789].total", mode)
+			assert.Len(t, result, 2, "mode %d", mode)
+
+			result = evaluate(t, "$.orders[?@.total == 0.3].id", mode)
+			assert.Len(t, result, 1, "mode %d", mode)
+		}
+	})
+
+	t.Run("exact decimal literals", func(t *testing.T) {
+		data := []byte(`[{"v": 1}, {"v": 1.0000000000000001}]`)
+		for _, mode := range []NumberMode{JSONNumbers, BigNumbers} {
+			eval := MustCompile("$[?@.v == 1.0]", WithNumberMode(mode))
+			result, err := eval.Evaluate(data)
+			require.NoError(t, err)
+			assert.Len(t, result, 1, "mode %d", mode)
+
+			eval = MustCompile("$[?@.v == 1.0000000000000001]", WithNumberMode(mode))
+			result, err = eval.Evaluate(data)
+			require.NoError(t, err)
+			assert.Len(t, result, 1, "mode %d", mode)
+
+			eval = MustCompile("$[?@.v > -1.0000000000000001 && @.v < 1.0000000000000001]", WithNumberMode(mode))
+			result, err = eval.Evaluate(data)
+			require.NoError(t, err)
+			assert.Len(t, result, 1, "mode %d", mode)
+		}
+
+		// Both of the values are the same float64.
+		result, err := MustCompile("$[?@.v == 1.0]").Evaluate(data)
+		require.NoError(t, err)
+		assert.Len(t, result, 2)
+	})
+
+	t.Run("large integer literals", func(t *testing.T) {
+		for _, mode := range []NumberMode{JSONNumbers, BigNumbers} {
+			result := evaluate(t, "$.orders[?@.id == 98765432109876543210].total", mode)
+			assert.Len(t, result, 1, "mode %d", mode)
+
+			result = evaluate(t, "$.orders[?@.id < 98765432109876543211].total", mode)
+			assert.Len(t, result, 3, "mode %d", mode)
+
+			result = evaluate(t, "$.orders[?@.id == 98765432109876543211].total", mode)
+			assert.Empty(t, result, "mode %d", mode)
+
+			result = evaluate(t, "$.orders[?@.id > -98765432109876543210].total", mode)
+			assert.Len(t, result, 3, "mode %d", mode)
+		}
+
+		parsed, err := Parse("$[?@.id == 12345678901234567891]")
+		require.NoError(t, err)
+		assert.Equal(t, "$[?@['id'] == 12345678901234567891]", parsed.String())
+	})
+
+	t.Run("float64 filter comparisons", func(t *testing.T) {
+		// The ids are rounded to the same float64, so both of them match.
+		result := evaluate(t, "$.orders[?@.id == 1234567890123456789].total", Float64Numbers)
+		assert.Equal(t, []interface{}{10.10, 0.30}, result)
+	})
+
+	t.Run("scanned and parsed paths agree", func(t *testing.T) {
+		scanned := evaluate(t, "$.orders[1].total", JSONNumbers)
+		parsed := evaluate(t, "$..[?@.id == 1234567890123456790].total", JSONNumbers)
+		assert.Equal(t, []interface{}{json.Number("0.30")}, scanned)
+		assert.Equal(t, scanned, parsed)
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		eval, err := NewEvaluator("$..id", WithNumberMode(BigNumbers))
+		require.NoError(t, err)
+
+		result, err := eval.Evaluate([]byte(`{"id": 1}}`))
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+func TestValuesEqual(t *testing.T) {
+	assert.True(t, valuesEqual(float64(1), int64(1)))
+	assert.True(t, valuesEqual(json.Number("1.0"), big.NewInt(1)))
+	assert.True(t, valuesEqual(
+		[]interface{}{json.Number("2"), "a"},
+		[]interface{}{float64(2), "a"},
+	))
+	assert.True(t, valuesEqual(
+		map[string]interface{}{"a": json.Number("1e1")},
+		map[string]interface{}{"a": int64(10)},
+	))
+	assert.False(t, valuesEqual(json.Number("1"), "1"))
+	assert.False(t, valuesEqual(
+		map[string]interface{}{"a": 1.0},
+		map[string]interface{}{"b": 1.0},
+	))
+}
@@ -1,7 +1,7 @@
 package jsonpath
 
 import (
-	"fmt"
+	"encoding/json"
 
 	"github.com/pkg/errors"
 )
@@ -9,37 +9,50 @@ import (
 type (
 	compiledJsonPath struct {
 		actions []jsonAction
+
+		// raw is used instead of the actions when the path can be evaluated
+		// by scanning the json, scannable will be true if that is the case.
+		raw       rawPath
+		scannable bool
+
+		// function is applied to the results of the actions, it is nil if
+		// the path does not end with a function.
+		function *compiledFunction
 	}
 
 	pathParser struct {
 		path   string
 		buffer *tokenBuffer
-	}
-
-	sliceAccessType uint8
-)
 
-const (
-	sliceAccessPrecise sliceAccessType = iota
-	sliceAccessRangeSimple
-	sliceAccessRangeComplex
-	sliceAccessList
+		// limits are checked while parsing so that deeply nested filters are
+		// rejected before the parser recurses into them. depth is how deeply
+		// the current filter expression is nested.
+		limits *Limits
+		depth  int
+	}
 )
 
 func parsePath(path string) (compiledJsonPath, error) {
-	parser, err := newPathParser(path)
+	parsed, err := Parse(path)
 	if err != nil {
 		return compiledJsonPath{}, err
 	}
 
-	actions, err := parser.Parse()
+	return compilePath(parsed)
+}
 
-	return compiledJsonPath{
-		actions: actions,
-	}, err
+// parseLimited will parse the jsonpath like Parse, but returns a LimitError as
+// soon as a filter is nested deeper than the limits allow.
+func parseLimited(path string, limits *Limits) (*Path, error) {
+	parser, err := newPathParser(path, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser.Parse()
 }
 
-func newPathParser(path string) (*pathParser, error) {
+func newPathParser(path string, limits *Limits) (*pathParser, error) {
 	buffer, err := newPathTokenBuffer(path)
 	if err != nil {
 		return nil, err
@@ -48,25 +61,92 @@ func newPathParser(path string) (*pathParser, error) {
 	return &pathParser{
 		path:   path,
 		buffer: buffer,
+		limits: limits,
 	}, nil
 }
 
-func (p *pathParser) Parse() ([]jsonAction, error) {
-	actions := make([]jsonAction, 0)
+func (p *pathParser) Parse() (*Path, error) {
+	// Whitespace is only insignificant between and within segments, a path
+	// cannot start with it.
+	if p.isWhitespaceNext() {
+		return nil, errors.Errorf("unexpected leading whitespace")
+	}
+
+	path := &Path{
+		Segments: make([]Segment, 0),
+	}
+
+	// The root identifier is optional, paths that do not start with it are
+	// still evaluated from the root. Those paths may start with a bare field
+	// name or wildcard.
+	if !p.consumeMaybe(dollar) {
+		switch p.buffer.Peek() {
+		case eof, openBracket, period:
+		default:
+			selector, err := p.parseDotSelector()
+			if err != nil {
+				return nil, err
+			}
+
+			path.Segments = append(path.Segments, ChildSegment{selector})
+		}
+	}
+
 	for {
+		// Whitespace is allowed before each segment, but if there is not
+		// another segment after it then it is trailing whitespace.
+		if p.skipWhitespace() && p.buffer.Peek() == eof {
+			return nil, errors.Errorf("unexpected trailing whitespace")
+		}
+
 		if p.buffer.Peek() == eof {
 			break
 		}
 
-		action, err := p.nextAction()
+		dotted := p.buffer.Peek() == period
+		segment, err := p.parseSegment()
 		if err != nil {
 			return nil, err
 		}
 
-		actions = append(actions, action)
+		if p.buffer.Peek() == openParen {
+			if path.Function, err = p.parseFunction(segment, dotted); err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		path.Segments = append(path.Segments, segment)
+	}
+
+	return path, nil
+}
+
+// parseFunction will parse the parentheses of a function at the end of a path,
+// like `.sum()`. The name of the function has already been parsed as the
+// segment before the parentheses.
+func (p *pathParser) parseFunction(segment Segment, dotted bool) (Function, error) {
+	child, ok := segment.(ChildSegment)
+	if !ok || !dotted || len(child) != 1 {
+		return "", errors.Errorf("unexpected '('")
+	}
+
+	name, ok := child[0].(NameSelector)
+	if !ok || !isFunction(string(name)) {
+		return "", errors.Errorf("unknown function %s()", child[0])
+	}
+
+	p.buffer.Scan()
+	if err := p.expectCharacterToken(closeParen); err != nil {
+		return "", err
+	}
+
+	if p.buffer.Peek() != eof {
+		return "", errors.Errorf("function %s() must be at the end of the path", name)
 	}
 
-	return actions, nil
+	return Function(name), nil
 }
 
 func (p *pathParser) expectCharacterToken(char characterToken) error {
@@ -89,6 +169,25 @@ func (p *pathParser) consumeMaybe(char characterToken) bool {
 	return false
 }
 
+// isWhitespaceNext will return true if the next token in the buffer is
+// whitespace. It does not consume the token.
+func (p *pathParser) isWhitespaceNext() bool {
+	_, ok := p.buffer.Peek().(whitespaceToken)
+	return ok
+}
+
+// skipWhitespace will consume all of the whitespace tokens at the current
+// position of the buffer. It returns true if any whitespace was consumed.
+func (p *pathParser) skipWhitespace() bool {
+	skipped := false
+	for p.isWhitespaceNext() {
+		p.buffer.Scan()
+		skipped = true
+	}
+
+	return skipped
+}
+
 func (p *pathParser) consumeInteger() (integerToken, bool) {
 	nextToken := p.buffer.Peek()
 	integer, ok := nextToken.(integerToken)
@@ -98,137 +197,440 @@ func (p *pathParser) consumeInteger() (integerToken, bool) {
 	return integer, ok
 }
 
-func (p *pathParser) nextAction() (jsonAction, error) {
+// consumeSignedInteger will consume an integer that may be prefixed with a
+// minus sign. If the next token is not the start of an integer then nothing
+// is consumed.
+func (p *pathParser) consumeSignedInteger() (int, bool, error) {
+	if !p.consumeMaybe(minus) {
+		integer, ok := p.consumeInteger()
+		return int(integer), ok, nil
+	}
+
+	// The minus sign must be immediately followed by the integer.
+	integer, ok := p.consumeInteger()
+	if !ok {
+		return 0, false, errors.Errorf("expected integer after '-'")
+	}
+
+	return -int(integer), true, nil
+}
+
+func (p *pathParser) parseSegment() (Segment, error) {
 	token := p.buffer.Peek()
-	switch t := token.(type) {
-	case stringToken:
-		return p.parseFieldAccess(p.buffer.Scan())
-	case characterToken:
-		switch t {
-		case dollar:
-			p.buffer.Scan()
-			return rootAccessAction{}, nil
-		case openBracket:
-			return p.parseBrackets()
-		case asterisk:
-			return p.parseFieldAccess(p.buffer.Scan())
-		case period:
-			p.buffer.Scan()
-			if nextToken := p.buffer.Peek(); nextToken == period {
-				// This is a recursive decent.
-				p.buffer.Scan()
-				return recursiveAction{}, nil
+	switch token {
+	case openBracket:
+		selectors, err := p.parseBrackets()
+		if err != nil {
+			return nil, err
+		}
+
+		return ChildSegment(selectors), nil
+	case period:
+		p.buffer.Scan()
+		if p.consumeMaybe(period) {
+			// This is a recursive decent.
+			if p.isWhitespaceNext() {
+				return nil, errors.Errorf("unexpected whitespace after '..'")
 			}
 
-			return p.parseFieldAccess(p.buffer.Scan())
-		default:
-			return nil, errors.Errorf("unexpected %T: %s", token, token)
+			if p.buffer.Peek() == openBracket {
+				selectors, err := p.parseBrackets()
+				if err != nil {
+					return nil, err
+				}
+
+				return DescendantSegment(selectors), nil
+			}
+
+			selector, err := p.parseDotSelector()
+			if err != nil {
+				return nil, err
+			}
+
+			return DescendantSegment{selector}, nil
+		}
+
+		if p.isWhitespaceNext() {
+			return nil, errors.Errorf("unexpected whitespace after '.'")
+		}
+
+		selector, err := p.parseDotSelector()
+		if err != nil {
+			return nil, err
 		}
+
+		return ChildSegment{selector}, nil
 	default:
-		return nil, errors.Errorf("unexpected %T: %s", token, token)
+		return nil, errors.Errorf("unexpected '%s'", token)
 	}
 }
 
-func (p *pathParser) parseBrackets() (jsonAction, error) {
-	// Check for array or inner expression.
-	p.buffer.Scan()
+// parseDotSelector will parse the field name or wildcard that follows a '.'
+// or '..' in a path.
+func (p *pathParser) parseDotSelector() (Selector, error) {
+	token := p.buffer.Scan()
+	switch raw := token.(type) {
+	case stringToken:
+		return NameSelector(raw), nil
+	case nullToken:
+		// Keywords are still valid field names when they follow a period.
+		return NameSelector("null"), nil
+	case booleanToken:
+		if raw {
+			return NameSelector("true"), nil
+		}
+
+		return NameSelector("false"), nil
+	case characterToken:
+		if raw == asterisk {
+			return WildcardSelector{}, nil
+		}
+
+		return nil, errors.Errorf("unexpected '%s' parsing field access", raw)
+	default:
+		return nil, errors.Errorf("unexpected '%s' parsing field access", token)
+	}
+}
 
-	var action jsonAction
-	var err error
+// parseBrackets will parse the comma delimited selectors within a pair of
+// brackets. Whitespace around each selector is insignificant.
+func (p *pathParser) parseBrackets() ([]Selector, error) {
+	if err := p.expectCharacterToken(openBracket); err != nil {
+		return nil, err
+	}
 
-	token := p.buffer.Scan()
+	selectors := make([]Selector, 0, 1)
+	for {
+		p.skipWhitespace()
+		selector, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+
+		selectors = append(selectors, selector)
+
+		p.skipWhitespace()
+		switch token := p.buffer.Scan(); token {
+		case comma: // Consume a comma token. We expect this to be comma delimited.
+		case closeBracket:
+			// Once we finally see a close bracket return the selectors.
+			return selectors, nil
+		default:
+			return nil, errors.Errorf("unexpected '%s' in brackets", token)
+		}
+	}
+}
+
+func (p *pathParser) parseSelector() (Selector, error) {
+	token := p.buffer.Peek()
 	switch t := token.(type) {
 	case singleQuotedStringToken, doubleQuotedStringToken, stringToken:
-		return p.parseFieldSliceAccess(t)
+		p.buffer.Scan()
+		field, err := p.parseString(t)
+		if err != nil {
+			return nil, err
+		}
+
+		return NameSelector(field), nil
 	case integerToken:
-		return p.parseSliceAccess(t)
+		return p.parseIndexOrSlice()
 	case characterToken:
 		// We might be performing an operation.
 		switch t {
-		case colon:
-			return p.parseSliceAccess(t)
+		case minus, colon:
+			return p.parseIndexOrSlice()
 		case question:
-			return nil, errors.Errorf("filtering not implemented")
+			p.buffer.Scan()
+			p.skipWhitespace()
+			expression, err := p.parseLogicalOr()
+			if err != nil {
+				return nil, err
+			}
+
+			return FilterSelector{
+				Expression: expression,
+			}, nil
 		case asterisk:
-			action, err = p.parseFieldAccess(t)
+			p.buffer.Scan()
+			return WildcardSelector{}, nil
 		default:
-			return nil, errors.Errorf("unexpected token '%s'", string(t))
+			return nil, errors.Errorf("unexpected token '%s'", t)
 		}
 	default:
-		return nil, errors.Errorf("somethings broken")
+		return nil, errors.Errorf("unexpected '%s' in brackets", token)
+	}
+}
+
+// parseIndexOrSlice will parse either a single index or a slice in the form
+// of [start:end:step] where each part of the slice is optional.
+func (p *pathParser) parseIndexOrSlice() (Selector, error) {
+	start, ok, err := p.consumeSignedInteger()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	if !p.consumeMaybe(colon) {
+		if !ok {
+			return nil, errors.Errorf("expected index")
+		}
+
+		return IndexSelector(start), nil
+	}
+
+	slice := SliceSelector{}
+	if ok {
+		slice.Start = &start
 	}
 
+	p.skipWhitespace()
+	end, ok, err := p.consumeSignedInteger()
 	if err != nil {
 		return nil, err
 	}
+	if ok {
+		slice.End = &end
+	}
 
-	return action, p.expectCharacterToken(closeBracket)
+	p.skipWhitespace()
+	if !p.consumeMaybe(colon) {
+		return slice, nil
+	}
+
+	p.skipWhitespace()
+	step, ok, err := p.consumeSignedInteger()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		slice.Step = &step
+	}
+
+	return slice, nil
 }
 
-func (p *pathParser) parseFieldSliceAccess(firstToken pathToken) (jsonAction, error) {
-	action := make(arrayFieldAccessAction, 0)
-	action = append(action, fmt.Sprint(firstToken))
+// parseLogicalOr will parse a filter expression, operators are parsed from
+// the lowest precedence (||) to the highest (!).
+func (p *pathParser) parseLogicalOr() (Expression, error) {
+	expressions := make(OrExpression, 0, 1)
 	for {
-		nextToken := p.buffer.Scan()
-		switch token := nextToken.(type) {
-		case stringToken, doubleQuotedStringToken, singleQuotedStringToken:
-			field, err := p.parseString(token)
-			if err != nil {
-				return nil, err
-			}
+		expression, err := p.parseLogicalAnd()
+		if err != nil {
+			return nil, err
+		}
 
-			action = append(action, field)
-		case characterToken:
-			switch token {
-			case comma: // Consume a comma token. We expect this to be comma delimited.
-			case closeBracket:
-				// Once we finally see a close bracket return the accessor.
-				return action, nil
-			default:
-				return nil, errors.Errorf("unexpected '%s' in slice field access", string(token))
-			}
-		default:
-			return nil, errors.Errorf("unexpected %T in slice field access", token)
+		expressions = append(expressions, expression)
+
+		p.skipWhitespace()
+		if p.buffer.Peek() != logicalOr {
+			break
 		}
+
+		p.buffer.Scan()
+		p.skipWhitespace()
 	}
-}
 
-func (p *pathParser) parseSliceAccess(firstToken pathToken) (jsonAction, error) {
-	indexes := make([]integerToken, 0)
+	if len(expressions) == 1 {
+		return expressions[0], nil
+	}
 
-	sliceAccessType := sliceAccessPrecise
+	return expressions, nil
+}
 
-	currentToken := firstToken
-ScanLoop:
+func (p *pathParser) parseLogicalAnd() (Expression, error) {
+	expressions := make(AndExpression, 0, 1)
 	for {
-		switch token := currentToken.(type) {
-		case integerToken:
-			indexes = append(indexes, token)
-		case characterToken:
-			switch token {
-			case closeBracket:
-				// If we find a closing bracket then we have reached the end of
-				// the index access area. Now parse the data we gathered.
-				break ScanLoop
-			case colon:
-				sliceAccessType = sliceAccessRangeSimple
-			case comma:
-				sliceAccessType = sliceAccessList
+		expression, err := p.parseBasicExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, expression)
+
+		p.skipWhitespace()
+		if p.buffer.Peek() != logicalAnd {
+			break
+		}
+
+		p.buffer.Scan()
+		p.skipWhitespace()
+	}
+
+	if len(expressions) == 1 {
+		return expressions[0], nil
+	}
+
+	return expressions, nil
+}
+
+func (p *pathParser) parseBasicExpression() (Expression, error) {
+	// Every negation, parenthesis and nested filter is parsed by recursing
+	// through here, so this is where the depth is limited.
+	p.depth++
+	defer func() {
+		p.depth--
+	}()
+
+	if err := p.limits.checkFilterDepth(p.depth); err != nil {
+		return nil, err
+	}
+
+	switch p.buffer.Peek() {
+	case exclamation:
+		p.buffer.Scan()
+		p.skipWhitespace()
+
+		var expression Expression
+		var err error
+		if p.buffer.Peek() == openParen {
+			expression, err = p.parseParenExpression()
+		} else {
+			expression, err = p.parseQuery()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return NotExpression{
+			Expression: expression,
+		}, nil
+	case openParen:
+		return p.parseParenExpression()
+	}
+
+	left, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	operator, ok := p.buffer.Peek().(comparisonToken)
+	if !ok {
+		// If there is no comparison then this must be an existence test,
+		// which is only valid for queries.
+		if _, ok := left.(QueryExpression); !ok {
+			return nil, errors.Errorf("unexpected literal %s in filter", left)
+		}
+
+		return left, nil
+	}
+
+	p.buffer.Scan()
+	p.skipWhitespace()
+
+	right, err := p.parseComparable()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, side := range []Expression{left, right} {
+		if query, ok := side.(QueryExpression); ok && !query.IsSingular() {
+			return nil, errors.Errorf("cannot compare non-singular query %s", query)
+		}
+	}
 
+	return ComparisonExpression{
+		Left:     left,
+		Operator: ComparisonOperator(operator),
+		Right:    right,
+	}, nil
+}
+
+func (p *pathParser) parseParenExpression() (Expression, error) {
+	if err := p.expectCharacterToken(openParen); err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	expression, err := p.parseLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	if err := p.expectCharacterToken(closeParen); err != nil {
+		return nil, err
+	}
+
+	return expression, nil
+}
+
+// parseComparable will parse either a literal or a query within a filter.
+func (p *pathParser) parseComparable() (Expression, error) {
+	token := p.buffer.Peek()
+	switch t := token.(type) {
+	case singleQuotedStringToken, doubleQuotedStringToken:
+		p.buffer.Scan()
+		str, err := p.parseString(t)
+		if err != nil {
+			return nil, err
+		}
+
+		return LiteralExpression{Value: str}, nil
+	case integerToken:
+		p.buffer.Scan()
+		return LiteralExpression{Value: int64(t)}, nil
+	case numberToken:
+		p.buffer.Scan()
+		return LiteralExpression{Value: json.Number(t)}, nil
+	case booleanToken:
+		p.buffer.Scan()
+		return LiteralExpression{Value: bool(t)}, nil
+	case nullToken:
+		p.buffer.Scan()
+		return LiteralExpression{Value: nil}, nil
+	case characterToken:
+		switch t {
+		case at, dollar:
+			return p.parseQuery()
+		case minus:
+			p.buffer.Scan()
+			switch number := p.buffer.Scan().(type) {
+			case integerToken:
+				return LiteralExpression{Value: -int64(number)}, nil
+			case numberToken:
+				return LiteralExpression{Value: json.Number("-" + number)}, nil
+			default:
+				return nil, errors.Errorf("expected number after '-'")
 			}
 		}
 
-		currentToken = p.buffer.Scan()
+		return nil, errors.Errorf("unexpected '%s' in filter", t)
+	default:
+		return nil, errors.Errorf("unexpected '%s' in filter", token)
 	}
+}
 
-	switch sliceAccessType {
-	case sliceAccessPrecise:
-		return arrayIndexAction(int(indexes[0])), nil
-	case sliceAccessList:
-		return newArrayIndexListAction(indexes), nil
+// parseQuery will parse a relative (@) or absolute ($) query within a filter.
+// The query ends at the first token that cannot start another segment.
+func (p *pathParser) parseQuery() (Expression, error) {
+	query := QueryExpression{
+		Segments: make([]Segment, 0),
 	}
 
-	return nil, errors.Errorf("bad index operation")
+	switch token := p.buffer.Scan(); token {
+	case at:
+		query.Relative = true
+	case dollar:
+	default:
+		return nil, errors.Errorf("unexpected '%s', expected '@' or '$'", token)
+	}
+
+	for {
+		p.skipWhitespace()
+		if token := p.buffer.Peek(); token != period && token != openBracket {
+			break
+		}
+
+		segment, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+
+		query.Segments = append(query.Segments, segment)
+	}
+
+	return query, nil
 }
 
 func (p *pathParser) parseString(token pathToken) (string, error) {
@@ -246,25 +648,3 @@ func (p *pathParser) parseString(token pathToken) (string, error) {
 
 	return field, nil
 }
-
-func (p *pathParser) parseFieldAccess(token pathToken) (jsonAction, error) {
-	var field string
-	var err error
-	switch raw := token.(type) {
-	case singleQuotedStringToken, doubleQuotedStringToken, stringToken:
-		field, err = p.parseString(raw)
-		if err != nil {
-			return nil, err
-		}
-	case characterToken:
-		if raw == asterisk {
-			return wildcardAccessAction{}, nil
-		}
-
-		return nil, errors.Errorf("unexpected '%s' parsing field access", string(raw))
-	default:
-		return nil, errors.Errorf("unexpected %T parsing field access", token)
-	}
-
-	return fieldAccessAction(field), nil
-}
@@ -14,4 +14,52 @@ func TestParse(t *testing.T) {
 
 		assert.NotEmpty(t, compiled)
 	})
+
+	t.Run("insignificant whitespace", func(t *testing.T) {
+		paths := []string{
+			"$ .address",
+			"$[ 'firstName' , 'lastName' ]",
+			"$\n\t['firstName',\r\n'lastName']",
+			"$.phoneNumbers[ * ]",
+			"$.phoneNumbers[ 0 , 1 ]",
+			"$ ..type",
+		}
+
+		for _, path := range paths {
+			compiled, err := parsePath(path)
+			assert.NoError(t, err, path)
+			assert.NotEmpty(t, compiled, path)
+		}
+	})
+
+	t.Run("significant whitespace", func(t *testing.T) {
+		paths := []string{
+			" $.address",
+			"$.address ",
+			"$. address",
+			"$.. type",
+			"$['firstName' 'lastName']",
+		}
+
+		for _, path := range paths {
+			_, err := parsePath(path)
+			assert.Error(t, err, path)
+		}
+	})
+	t.Run("error messages", func(t *testing.T) {
+		messages := map[string]string{
+			"$.a[0 1]":     "unexpected '1' in brackets",
+			"$.a[0 true]":  "unexpected 'true' in brackets",
+			"$[1 null]":    "unexpected 'null' in brackets",
+			"$.a[0 1.5]":   "unexpected '1.5' in brackets",
+			"$ 1":          "unexpected '1'",
+			"$.a[?@.b 1]":  "unexpected '1' in brackets",
+			"$.a[?@.b ==]": "unexpected ']' in filter",
+		}
+
+		for path, message := range messages {
+			_, err := Parse(path)
+			assert.EqualError(t, err, message, path)
+		}
+	})
 }
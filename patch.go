@@ -0,0 +1,525 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The operations of a JSON Patch.
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+type (
+	// Patch is a JSON Patch document as defined by RFC 6902. It can be
+	// marshalled to json and shown before it is applied.
+	Patch []PatchOperation
+
+	// PatchOperation is a single operation of a Patch. Path and From are JSON
+	// Pointers, From is only used by move and copy operations.
+	PatchOperation struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		From  string          `json:"from,omitempty"`
+		Value json.RawMessage `json:"value,omitempty"`
+	}
+)
+
+// SetPatch will return a Patch that replaces every value that the jsonpath
+// matches in the provided json with value. If a singular jsonpath does not
+// match anything but its parent is an object, then the Patch will add the
+// value instead.
+func (e *Evaluator) SetPatch(data []byte, value interface{}) (Patch, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal value")
+	}
+
+	nodes, err := e.locate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(Patch, 0, len(nodes))
+	for _, node := range nodes {
+		patch = append(patch, PatchOperation{
+			Op:    PatchReplace,
+			Path:  node.location.Pointer(),
+			Value: raw,
+		})
+	}
+
+	if len(nodes) == 0 {
+		pointer, ok, err := e.addPointer(data)
+		if err != nil || !ok {
+			return patch, err
+		}
+
+		patch = append(patch, PatchOperation{
+			Op:    PatchAdd,
+			Path:  pointer,
+			Value: raw,
+		})
+	}
+
+	return patch, nil
+}
+
+// DeletePatch will return a Patch that removes every value that the jsonpath
+// matches in the provided json. Elements of an array are removed from the
+// last to the first so that removing one does not change the index of the
+// others, and values within a value that is removed are not removed again.
+func (e *Evaluator) DeletePatch(data []byte) (Patch, error) {
+	nodes, err := e.locate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([][]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		if node.location.parent == nil {
+			return nil, errors.Errorf("cannot remove the root of the document")
+		}
+
+		keys = append(keys, node.location.keys())
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return compareKeys(keys[i], keys[j]) < 0
+	})
+
+	// Any descendants of a location come directly after it once they are
+	// sorted, so only the last location that is kept needs to be checked.
+	kept := make([][]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if len(kept) > 0 && isKeyPrefix(kept[len(kept)-1], key) {
+			continue
+		}
+
+		kept = append(kept, key)
+	}
+
+	patch := make(Patch, len(kept))
+	for i, key := range kept {
+		patch[len(kept)-1-i] = PatchOperation{
+			Op:   PatchRemove,
+			Path: keysPointer(key),
+		}
+	}
+
+	return patch, nil
+}
+
+// TransformPatch will return a Patch that replaces every value that the
+// jsonpath matches in the provided json with the result of calling fn with
+// that value. If fn returns an error then no Patch is returned.
+func (e *Evaluator) TransformPatch(data []byte, fn func(value interface{}) (interface{}, error)) (Patch, error) {
+	nodes, err := e.locate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(Patch, 0, len(nodes))
+	for _, node := range nodes {
+		pointer := node.location.Pointer()
+		value, err := fn(node.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to transform %s", pointer)
+		}
+
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal the value for %s", pointer)
+		}
+
+		patch = append(patch, PatchOperation{
+			Op:    PatchReplace,
+			Path:  pointer,
+			Value: raw,
+		})
+	}
+
+	return patch, nil
+}
+
+// locate will evaluate the jsonpath while tracking the location of each node.
+func (e *Evaluator) locate(data []byte) ([]locatedNode, error) {
+	if err := e.checkLocations(); err != nil {
+		return nil, err
+	}
+
+	node, err := e.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.run(locatedNode{value: node, location: rootLocation()}, nil)
+}
+
+// addPointer will return the pointer that a value can be added at if the
+// jsonpath is singular and its parent is an object.
+func (e *Evaluator) addPointer(data []byte) (string, bool, error) {
+	path, err := Parse(e.path)
+	if err != nil {
+		return "", false, err
+	}
+
+	pointer, err := path.Pointer()
+	if err != nil || len(path.Segments) == 0 {
+		// Only singular paths can be added.
+		return "", false, nil
+	}
+
+	if _, ok := path.Segments[len(path.Segments)-1].(ChildSegment)[0].(NameSelector); !ok {
+		return "", false, nil
+	}
+
+	parent, err := newPathEvaluator(&Path{
+		Segments: path.Segments[:len(path.Segments)-1],
+	}, nil)
+	if err != nil {
+		return "", false, err
+	}
+	parent.numbers, parent.limits = e.numbers, e.limits
+
+	nodes, err := parent.locate(data)
+	if err != nil || len(nodes) == 0 || !isObject(nodes[0].value) {
+		return "", false, err
+	}
+
+	return pointer, true, nil
+}
+
+// ApplyPatch will apply the JSON Patch document to the provided json and
+// return the patched json. See Patch.Apply.
+func ApplyPatch(data, patch []byte) ([]byte, error) {
+	var operations Patch
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal patch")
+	}
+
+	return operations.Apply(data)
+}
+
+// Apply will apply each of the operations of the Patch to the provided json in
+// order and return the patched json. If any of the operations fail then an
+// error is returned and none of the operations are applied. Numbers are kept
+// exactly as they were written, but the members of objects are written in
+// order of their keys. The document must be json, even if the Patch was
+// created by an Evaluator with a Decoder.
+func (p Patch) Apply(data []byte) ([]byte, error) {
+	document, err := decodeJson(data, JSONNumbers)
+	if err != nil {
+		return nil, errors.Wrap(err, "patches can only be applied to json documents")
+	}
+
+	for i, operation := range p {
+		if document, err = operation.apply(document); err != nil {
+			return nil, errors.Wrapf(err, "failed to apply operation %d (%s %s)", i, operation.Op, operation.Path)
+		}
+	}
+
+	return encodeJson(document)
+}
+
+func (o PatchOperation) apply(document jsonNode) (jsonNode, error) {
+	path, err := pointerTokens(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		if o.Value == nil {
+			return nil, errors.Errorf("%s operation is missing a value", o.Op)
+		}
+
+		value, err := decodeJson(o.Value, JSONNumbers)
+		if err != nil {
+			return nil, err
+		}
+
+		switch o.Op {
+		case PatchAdd:
+			return addValue(document, path, value)
+		case PatchReplace:
+			return replaceValue(document, path, value)
+		default:
+			current, err := getValue(document, path)
+			if err != nil {
+				return nil, err
+			}
+
+			if !valuesEqual(current, value) {
+				return nil, errors.Errorf("value at %s is not equal to the test value", o.Path)
+			}
+
+			return document, nil
+		}
+	case PatchRemove:
+		return removeValue(document, path)
+	case PatchMove, PatchCopy:
+		from, err := pointerTokens(o.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := getValue(document, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == PatchCopy {
+			return addValue(document, path, copyValue(value))
+		}
+
+		if len(from) < len(path) && isKeyPrefix(stringKeys(from), stringKeys(path)) {
+			return nil, errors.Errorf("cannot move %s into one of its children", o.From)
+		}
+
+		if document, err = removeValue(document, from); err != nil {
+			return nil, err
+		}
+
+		return addValue(document, path, value)
+	default:
+		return nil, errors.Errorf("unknown operation '%s'", o.Op)
+	}
+}
+
+// getValue returns the value at the path within the document.
+func getValue(document jsonNode, path []string) (jsonNode, error) {
+	current := document
+	for i, token := range path {
+		switch data := current.(type) {
+		case jsonObject:
+			value, ok := data[token]
+			if !ok {
+				return nil, errors.Errorf("%s does not exist", tokensPointer(path[:i+1]))
+			}
+
+			current = value
+		case jsonArray:
+			index, err := arrayIndex(token, len(data)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			current = data[index]
+		default:
+			return nil, errors.Errorf("%s does not exist", tokensPointer(path[:i+1]))
+		}
+	}
+
+	return current, nil
+}
+
+// addValue will add the value to the document at the path. Adding to an
+// object will replace any member with the same name, adding to an array will
+// insert the value before the element at the index.
+func addValue(document jsonNode, path []string, value jsonNode) (jsonNode, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(document, path, func(parent jsonNode, token string) (jsonNode, error) {
+		switch data := parent.(type) {
+		case jsonObject:
+			data[token] = value
+			return data, nil
+		case jsonArray:
+			index := len(data)
+			if token != "-" {
+				var err error
+				if index, err = arrayIndex(token, len(data)); err != nil {
+					return nil, err
+				}
+			}
+
+			data = append(data, nil)
+			copy(data[index+1:], data[index:])
+			data[index] = value
+
+			return data, nil
+		default:
+			return nil, errors.Errorf("cannot add to a value that is not an object or an array")
+		}
+	})
+}
+
+// replaceValue will replace the value at the path, which must exist.
+func replaceValue(document jsonNode, path []string, value jsonNode) (jsonNode, error) {
+	if _, err := getValue(document, path); err != nil {
+		return nil, err
+	}
+
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	// The value exists, so the parent must be an object or an array.
+	return updateParent(document, path, func(parent jsonNode, token string) (jsonNode, error) {
+		if object, ok := parent.(jsonObject); ok {
+			object[token] = value
+			return object, nil
+		}
+
+		array := parent.(jsonArray)
+		index, _ := arrayIndex(token, len(array)-1)
+		array[index] = value
+
+		return array, nil
+	})
+}
+
+// removeValue will remove the value at the path, which must exist.
+func removeValue(document jsonNode, path []string) (jsonNode, error) {
+	if _, err := getValue(document, path); err != nil {
+		return nil, err
+	}
+
+	if len(path) == 0 {
+		return nil, errors.Errorf("cannot remove the root of the document")
+	}
+
+	// The value exists, so the parent must be an object or an array.
+	return updateParent(document, path, func(parent jsonNode, token string) (jsonNode, error) {
+		if object, ok := parent.(jsonObject); ok {
+			delete(object, token)
+			return object, nil
+		}
+
+		array := parent.(jsonArray)
+		index, _ := arrayIndex(token, len(array)-1)
+
+		return append(array[:index], array[index+1:]...), nil
+	})
+}
+
+// updateParent will call fn with the parent of the value at the path and the
+// last token of the path. The parent is replaced with the value that fn
+// returns, which allows arrays to change their length.
+func updateParent(document jsonNode, path []string, fn func(parent jsonNode, token string) (jsonNode, error)) (jsonNode, error) {
+	parent, err := getValue(document, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := fn(parent, path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(path) == 1 {
+		return updated, nil
+	}
+
+	// The parent of an object is not changed when the object is, but arrays
+	// may have been reallocated so the grandparent needs to be updated.
+	if _, ok := updated.(jsonArray); !ok {
+		return document, nil
+	}
+
+	return replaceValue(document, path[:len(path)-1], updated)
+}
+
+// arrayIndex will parse the token as an index of an array, the index must not
+// be greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	if !isPointerIndex(token) {
+		return 0, errors.Errorf("'%s' is not an array index", token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, errors.Errorf("index %s is out of bounds", token)
+	}
+
+	return index, nil
+}
+
+// copyValue returns a deep copy of the value so that changing the copy does
+// not change the original.
+func copyValue(value jsonNode) jsonNode {
+	switch data := value.(type) {
+	case jsonArray:
+		copied := make(jsonArray, len(data))
+		for i, item := range data {
+			copied[i] = copyValue(item)
+		}
+
+		return copied
+	case jsonObject:
+		copied := make(jsonObject, len(data))
+		for key, item := range data {
+			copied[key] = copyValue(item)
+		}
+
+		return copied
+	default:
+		return value
+	}
+}
+
+// compareKeys will order the keys of two locations. Indexes are compared as
+// numbers, and a location comes before any of its descendants.
+func compareKeys(left, right []interface{}) int {
+	for i := 0; i < len(left) && i < len(right); i++ {
+		switch l := left[i].(type) {
+		case int:
+			if r, ok := right[i].(int); ok && l != r {
+				if l < r {
+					return -1
+				}
+
+				return 1
+			}
+		case string:
+			if r, ok := right[i].(string); ok && l != r {
+				return strings.Compare(l, r)
+			}
+		}
+	}
+
+	return len(left) - len(right)
+}
+
+// isKeyPrefix returns true if the location of prefix is the same as, or an
+// ancestor of, the location of keys.
+func isKeyPrefix(prefix, keys []interface{}) bool {
+	if len(prefix) > len(keys) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != keys[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tokensPointer returns the JSON Pointer for the tokens of a path.
+func tokensPointer(tokens []string) string {
+	return keysPointer(stringKeys(tokens))
+}
+
+// stringKeys converts the tokens of a pointer into location keys.
+func stringKeys(tokens []string) []interface{} {
+	keys := make([]interface{}, len(tokens))
+	for i, token := range tokens {
+		keys[i] = token
+	}
+
+	return keys
+}
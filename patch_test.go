@@ -0,0 +1,180 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_SetPatch(t *testing.T) {
+	t.Run("replace", func(t *testing.T) {
+		patch, err := MustCompile("$.store.book[?@.price < 10].price").SetPatch([]byte(StoreJson), 5)
+		require.NoError(t, err)
+		assert.Equal(t, Patch{
+			{Op: PatchReplace, Path: "/store/book/0/price", Value: json.RawMessage(`5`)},
+			{Op: PatchReplace, Path: "/store/book/2/price", Value: json.RawMessage(`5`)},
+		}, patch)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		patch, err := MustCompile("$.store.bicycle.brand").SetPatch([]byte(StoreJson), "acme")
+		require.NoError(t, err)
+		assert.Equal(t, Patch{
+			{Op: PatchAdd, Path: "/store/bicycle/brand", Value: json.RawMessage(`"acme"`)},
+		}, patch)
+
+		// The parent does not exist so nothing can be added.
+		patch, err = MustCompile("$.store.car.brand").SetPatch([]byte(StoreJson), "acme")
+		require.NoError(t, err)
+		assert.Empty(t, patch)
+
+		// Only singular paths are added.
+		patch, err = MustCompile("$..brand").SetPatch([]byte(StoreJson), "acme")
+		require.NoError(t, err)
+		assert.Empty(t, patch)
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		patch, err := MustCompile("$.expensive").SetPatch([]byte(StoreJson), nil)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(patch)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"op": "replace", "path": "/expensive", "value": null}]`, string(data))
+	})
+}
+
+func TestEvaluator_DeletePatch(t *testing.T) {
+	t.Run("array elements", func(t *testing.T) {
+		patch, err := MustCompile("$.store.book[?@.category == 'fiction']").DeletePatch([]byte(StoreJson))
+		require.NoError(t, err)
+		assert.Equal(t, Patch{
+			{Op: PatchRemove, Path: "/store/book/3"},
+			{Op: PatchRemove, Path: "/store/book/2"},
+			{Op: PatchRemove, Path: "/store/book/1"},
+		}, patch)
+
+		result, err := patch.Apply([]byte(StoreJson))
+		require.NoError(t, err)
+
+		titles, err := MustCompile("$.store.book[*].title").Evaluate(result)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"Sayings of the Century"}, titles)
+	})
+
+	t.Run("nested and duplicates", func(t *testing.T) {
+		data := []byte(`{"a": {"b": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11]}, "c": 1}`)
+		patch, err := MustCompile("$..[?@ != 3]").DeletePatch(data)
+		require.NoError(t, err)
+		assert.Equal(t, Patch{
+			{Op: PatchRemove, Path: "/c"},
+			{Op: PatchRemove, Path: "/a"},
+		}, patch)
+
+		patch, err = MustCompile("$.a.b[1,10,2,1,-1]").DeletePatch(data)
+		require.NoError(t, err)
+		assert.Equal(t, Patch{
+			{Op: PatchRemove, Path: "/a/b/10"},
+			{Op: PatchRemove, Path: "/a/b/2"},
+			{Op: PatchRemove, Path: "/a/b/1"},
+		}, patch)
+
+		result, err := patch.Apply(data)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a": {"b": [1, 4, 5, 6, 7, 8, 9, 10]}, "c": 1}`, string(result))
+	})
+
+	t.Run("root", func(t *testing.T) {
+		_, err := MustCompile("$").DeletePatch([]byte(StoreJson))
+		assert.EqualError(t, err, "cannot remove the root of the document")
+	})
+}
+
+func TestEvaluator_TransformPatch(t *testing.T) {
+	patch, err := MustCompile("$..author").TransformPatch([]byte(StoreJson), func(value interface{}) (interface{}, error) {
+		return len(value.(string)), nil
+	})
+	require.NoError(t, err)
+	require.Len(t, patch, 4)
+	assert.Equal(t, PatchOperation{Op: PatchReplace, Path: "/store/book/0/author", Value: json.RawMessage(`10`)}, patch[0])
+
+	_, err = MustCompile("$..author").TransformPatch([]byte(StoreJson), func(value interface{}) (interface{}, error) {
+		return nil, assert.AnError
+	})
+	assert.EqualError(t, err, "failed to transform /store/book/0/author: "+assert.AnError.Error())
+}
+
+func TestApplyPatch(t *testing.T) {
+	const document = `{"a": {"b": [1, 2]}, "id": 1234567890123456789, "c": "d"}`
+
+	valid := []struct {
+		name     string
+		patch    string
+		expected string
+	}{
+		{"add member", `[{"op": "add", "path": "/a/x", "value": {"y": null}}]`, `{"a": {"b": [1, 2], "x": {"y": null}}, "id": 1234567890123456789, "c": "d"}`},
+		{"add element", `[{"op": "add", "path": "/a/b/1", "value": 5}]`, `{"a": {"b": [1, 5, 2]}, "id": 1234567890123456789, "c": "d"}`},
+		{"append", `[{"op": "add", "path": "/a/b/-", "value": 3}, {"op": "add", "path": "/a/b/3", "value": 4}]`, `{"a": {"b": [1, 2, 3, 4]}, "id": 1234567890123456789, "c": "d"}`},
+		{"replace root", `[{"op": "add", "path": "", "value": [1]}]`, `[1]`},
+		{"remove", `[{"op": "remove", "path": "/a/b/0"}, {"op": "remove", "path": "/c"}]`, `{"a": {"b": [2]}, "id": 1234567890123456789}`},
+		{"replace", `[{"op": "replace", "path": "/c", "value": null}]`, `{"a": {"b": [1, 2]}, "id": 1234567890123456789, "c": null}`},
+		{"move", `[{"op": "move", "from": "/a/b", "path": "/b"}]`, `{"a": {}, "b": [1, 2], "id": 1234567890123456789, "c": "d"}`},
+		{"copy", `[{"op": "copy", "from": "/a", "path": "/e"}, {"op": "add", "path": "/e/b/-", "value": 3}]`, `{"a": {"b": [1, 2]}, "e": {"b": [1, 2, 3]}, "id": 1234567890123456789, "c": "d"}`},
+		{"test", `[{"op": "test", "path": "/id", "value": 1234567890123456789}, {"op": "test", "path": "/a/b", "value": [1, 2.0]}]`, document},
+	}
+	for _, test := range valid {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := ApplyPatch([]byte(document), []byte(test.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(result))
+		})
+	}
+
+	invalid := []struct {
+		name  string
+		patch string
+		err   string
+	}{
+		{"missing value", `[{"op": "add", "path": "/x"}]`, "failed to apply operation 0 (add /x): add operation is missing a value"},
+		{"missing member", `[{"op": "remove", "path": "/x/y"}]`, "failed to apply operation 0 (remove /x/y): /x does not exist"},
+		{"out of bounds", `[{"op": "replace", "path": "/a/b/2", "value": 1}]`, "failed to apply operation 0 (replace /a/b/2): index 2 is out of bounds"},
+		{"bad index", `[{"op": "add", "path": "/a/b/01", "value": 1}]`, "failed to apply operation 0 (add /a/b/01): '01' is not an array index"},
+		{"add to scalar", `[{"op": "add", "path": "/c/x", "value": 1}]`, "failed to apply operation 0 (add /c/x): cannot add to a value that is not an object or an array"},
+		{"remove root", `[{"op": "remove", "path": ""}]`, "failed to apply operation 0 (remove ): cannot remove the root of the document"},
+		{"move into child", `[{"op": "move", "from": "/a", "path": "/a/x"}]`, "failed to apply operation 0 (move /a/x): cannot move /a into one of its children"},
+		{"failed test", `[{"op": "remove", "path": "/c"}, {"op": "test", "path": "/id", "value": 1234567890123456788}]`, "failed to apply operation 1 (test /id): value at /id is not equal to the test value"},
+		{"unknown", `[{"op": "merge", "path": "/c"}]`, "failed to apply operation 0 (merge /c): unknown operation 'merge'"},
+		{"bad pointer", `[{"op": "remove", "path": "c"}]`, "failed to apply operation 0 (remove c): json pointer must start with '/'"},
+	}
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := ApplyPatch([]byte(document), []byte(test.patch))
+			assert.EqualError(t, err, test.err)
+			assert.Nil(t, result)
+		})
+	}
+
+	t.Run("html characters", func(t *testing.T) {
+		result, err := ApplyPatch([]byte(`{"a": "<x>&", "b": 1}`), []byte(`[{"op": "replace", "path": "/b", "value": "<y>"}]`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"a":"<x>&","b":"<y>"}`, string(result))
+	})
+
+	t.Run("bad patch", func(t *testing.T) {
+		_, err := ApplyPatch([]byte(document), []byte(`{"op": "add"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		data := []byte("a: 1")
+		patch, err := MustCompile("$.a", WithDecoder(YAMLDecoder)).SetPatch(data, 2)
+		require.NoError(t, err)
+		assert.Equal(t, Patch{{Op: PatchReplace, Path: "/a", Value: json.RawMessage(`2`)}}, patch)
+
+		_, err = patch.Apply(data)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "patches can only be applied to json documents")
+	})
+}
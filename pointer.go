@@ -0,0 +1,250 @@
+package jsonpath
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// ToPointer will convert the provided jsonpath into a JSON Pointer as defined
+// by RFC 6901. Only singular jsonpaths that are made up of names and
+// non-negative indexes can be converted, for example `$.store.book[0]` is
+// converted to `/store/book/0`.
+func ToPointer(path string) (string, error) {
+	parsed, err := Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.Pointer()
+}
+
+// FromPointer will convert the provided JSON Pointer into a jsonpath in its
+// canonical form. See ParsePointer for how the tokens of the pointer are
+// converted.
+func FromPointer(pointer string) (string, error) {
+	path, err := ParsePointer(pointer)
+	if err != nil {
+		return "", err
+	}
+
+	return path.String(), nil
+}
+
+// ParsePointer will parse the provided JSON Pointer into a Path. A JSON
+// Pointer does not say whether a token is a name or an index, so tokens that
+// are array indexes, such as `0` or `12`, are converted to an IndexSelector
+// and any other token is converted to a NameSelector. This means that a
+// pointer to an object member named like an index, such as `/o/0` from
+// `$.o['0']`, is not converted back to the same path. Use ResolvePointer to
+// convert these pointers using the json that they point into.
+func ParsePointer(pointer string) (*Path, error) {
+	tokens, err := pointerTokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	path := &Path{
+		Segments: make([]Segment, 0, len(tokens)),
+	}
+	for _, token := range tokens {
+		path.Segments = append(path.Segments, ChildSegment{pointerSelector(token)})
+	}
+
+	return path, nil
+}
+
+// ResolvePointer will parse the provided JSON Pointer into a Path like
+// ParsePointer, but will use the provided json to decide whether each token is
+// a name or an index. A token is always a name when the value it is applied to
+// is an object, so `/o/0` is converted to `$['o']['0']` when `o` is an object.
+// Tokens after a value that does not exist are converted like ParsePointer.
+func ResolvePointer(data []byte, pointer string) (*Path, error) {
+	tokens, err := pointerTokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := parseJson(data)
+	if err != nil {
+		return nil, err
+	}
+
+	path := &Path{
+		Segments: make([]Segment, 0, len(tokens)),
+	}
+	for _, token := range tokens {
+		if object, ok := current.(jsonObject); ok {
+			current = object[token]
+			path.Segments = append(path.Segments, ChildSegment{NameSelector(token)})
+			continue
+		}
+
+		selector := pointerSelector(token)
+		array, _ := current.(jsonArray)
+		current = nil
+		if index, ok := selector.(IndexSelector); ok && int(index) < len(array) {
+			current = array[index]
+		}
+
+		path.Segments = append(path.Segments, ChildSegment{selector})
+	}
+
+	return path, nil
+}
+
+// pointerSelector returns the selector for a single token of a JSON Pointer.
+// An index that is too large to be an int cannot select an element of any
+// array, so it is used as a name instead.
+func pointerSelector(token string) Selector {
+	if !isPointerIndex(token) {
+		return NameSelector(token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return NameSelector(token)
+	}
+
+	return IndexSelector(index)
+}
+
+// Pointer will convert the path into a JSON Pointer. An error is returned if
+// the path is not singular, if it has a negative index or if it ends with a
+// function.
+func (p *Path) Pointer() (string, error) {
+	if p.Function != "" {
+		return "", errors.Errorf("%s ends with a function", p)
+	}
+
+	var builder strings.Builder
+	for _, segment := range p.Segments {
+		child, ok := segment.(ChildSegment)
+		if !ok || len(child) != 1 {
+			return "", errors.Errorf("%s is not singular", p)
+		}
+
+		builder.WriteByte('/')
+		switch s := child[0].(type) {
+		case NameSelector:
+			builder.WriteString(pointerEscaper.Replace(string(s)))
+		case IndexSelector:
+			if s < 0 {
+				return "", errors.Errorf("negative index %d cannot be a json pointer", s)
+			}
+
+			builder.WriteString(strconv.Itoa(int(s)))
+		default:
+			return "", errors.Errorf("%s is not singular", p)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// EvaluatePointers will run the compiled jsonpath against the provided json
+// and return the JSON Pointer of each match instead of its value.
+func (e *Evaluator) EvaluatePointers(data []byte) ([]string, error) {
+	if err := e.checkLocations(); err != nil {
+		return nil, err
+	}
+
+	node, err := e.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := e.run(locatedNode{value: node, location: rootLocation()}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	pointers := make([]string, len(nodes))
+	for i, node := range nodes {
+		pointers[i] = node.location.Pointer()
+	}
+
+	return pointers, nil
+}
+
+// Pointer returns the JSON Pointer of the location, for example
+// `/store/book/0`.
+func (l *location) Pointer() string {
+	return keysPointer(l.keys())
+}
+
+// keysPointer returns the JSON Pointer for the keys of a location.
+func keysPointer(keys []interface{}) string {
+	var builder strings.Builder
+	for _, key := range keys {
+		builder.WriteByte('/')
+		switch k := key.(type) {
+		case string:
+			builder.WriteString(pointerEscaper.Replace(k))
+		case int:
+			builder.WriteString(strconv.Itoa(k))
+		}
+	}
+
+	return builder.String()
+}
+
+// pointerTokens will split the pointer into its unescaped tokens.
+func pointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, errors.Errorf("json pointer must start with '/'")
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		if err := validatePointerToken(token); err != nil {
+			return nil, err
+		}
+
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+
+	return tokens, nil
+}
+
+// validatePointerToken will make sure that every ~ in the token is part of an
+// escape sequence.
+func validatePointerToken(token string) error {
+	for i := 0; i < len(token); i++ {
+		if token[i] != '~' {
+			continue
+		}
+
+		if i+1 >= len(token) || (token[i+1] != '0' && token[i+1] != '1') {
+			return errors.Errorf("invalid escape in json pointer token '%s'", token)
+		}
+	}
+
+	return nil
+}
+
+// isPointerIndex returns true if the token is an array index. Indexes cannot
+// have leading zeros.
+func isPointerIndex(token string) bool {
+	if token == "" || (token[0] == '0' && len(token) > 1) {
+		return false
+	}
+
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
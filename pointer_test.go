@@ -0,0 +1,147 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPointer(t *testing.T) {
+	pointers := map[string]string{
+		"$":                     "",
+		"$.store.book[0].title": "/store/book/0/title",
+		"$['a/b']['m~n']":       "/a~1b/m~0n",
+		"$['']":                 "/",
+		"$[' '][1]":             "/ /1",
+		"$['0']":                "/0",
+	}
+	for path, expected := range pointers {
+		pointer, err := ToPointer(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, expected, pointer, path)
+	}
+
+	invalid := map[string]string{
+		"$..book":       "$..['book'] is not singular",
+		"$.store.*":     "$['store'][*] is not singular",
+		"$['a','b']":    "$['a','b'] is not singular",
+		"$.book[-1]":    "negative index -1 cannot be a json pointer",
+		"$.book[?@.id]": "$['book'][?@['id']] is not singular",
+	}
+	for path, expected := range invalid {
+		_, err := ToPointer(path)
+		assert.EqualError(t, err, expected, path)
+	}
+
+	_, err := ToPointer("$[")
+	assert.Error(t, err)
+}
+
+func TestFromPointer(t *testing.T) {
+	paths := map[string]string{
+		"":                      "$",
+		"/":                     "$['']",
+		"/store/book/0/title":   "$['store']['book'][0]['title']",
+		"/a~1b/m~0n":            "$['a/b']['m~n']",
+		"/~01":                  "$['~1']",
+		"/01":                   "$['01']",
+		"/-":                    "$['-']",
+		"/it's":                 `$['it\'s']`,
+		"/99999999999999999999": "$['99999999999999999999']",
+	}
+	for pointer, expected := range paths {
+		path, err := FromPointer(pointer)
+		require.NoError(t, err, pointer)
+		assert.Equal(t, expected, path, pointer)
+	}
+
+	invalid := map[string]string{
+		"store":   "json pointer must start with '/'",
+		"/a~":     "invalid escape in json pointer token 'a~'",
+		"/a~2":    "invalid escape in json pointer token 'a~2'",
+		"/a/~x/b": "invalid escape in json pointer token '~x'",
+	}
+	for pointer, expected := range invalid {
+		_, err := FromPointer(pointer)
+		assert.EqualError(t, err, expected, pointer)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		for _, pointer := range []string{"", "/a~1b/m~0n/3", "/store/book/0"} {
+			path, err := ParsePointer(pointer)
+			require.NoError(t, err)
+
+			result, err := path.Pointer()
+			require.NoError(t, err)
+			assert.Equal(t, pointer, result)
+		}
+	})
+}
+
+func TestEvaluator_EvaluatePointers(t *testing.T) {
+	pointers, err := MustCompile("$..book[?@.price < 10]['title','isbn']").EvaluatePointers([]byte(StoreJson))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/store/book/0/title",
+		"/store/book/2/title",
+		"/store/book/2/isbn",
+	}, pointers)
+
+	pointers, err = MustCompile("$.store.book[-1:]").EvaluatePointers([]byte(StoreJson))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/store/book/3"}, pointers)
+
+	_, err = MustCompile("$.store").EvaluatePointers([]byte(`{"store":`))
+	assert.Error(t, err)
+}
+
+func TestResolvePointer(t *testing.T) {
+	data := []byte(`{"o": {"0": 1, "1": [{"2": true}]}, "a": [10, 20]}`)
+
+	paths := map[string]string{
+		"":                        "$",
+		"/o/0":                    "$['o']['0']",
+		"/o/1/0/2":                "$['o']['1'][0]['2']",
+		"/a/1":                    "$['a'][1]",
+		"/a/5/0":                  "$['a'][5][0]",
+		"/a/-":                    "$['a']['-']",
+		"/missing/0":              "$['missing'][0]",
+		"/a/99999999999999999999": "$['a']['99999999999999999999']",
+	}
+	for pointer, expected := range paths {
+		path, err := ResolvePointer(data, pointer)
+		require.NoError(t, err, pointer)
+		assert.Equal(t, expected, path.String(), pointer)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		data := []byte(`{"o": {"0": 1}, "a": [{"0": 2}]}`)
+
+		pointers, err := MustCompile("$..['0']").EvaluatePointers(data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/a/0/0", "/o/0"}, pointers)
+
+		for i, expected := range []interface{}{2.0, 1.0} {
+			path, err := ResolvePointer(data, pointers[i])
+			require.NoError(t, err)
+
+			result, err := MustCompile(path.String()).Evaluate(data)
+			require.NoError(t, err)
+			assert.Equal(t, []interface{}{expected}, result, pointers[i])
+		}
+
+		// Without the json the numeric token is converted to an index.
+		path, err := FromPointer("/o/0")
+		require.NoError(t, err)
+		assert.Equal(t, "$['o'][0]", path)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ResolvePointer(data, "o")
+		assert.EqualError(t, err, "json pointer must start with '/'")
+
+		_, err = ResolvePointer([]byte(`{"o":`), "/o")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,213 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// UnwrapMode is how a Projection decides whether a field is a single value or
+// an array of every result.
+type UnwrapMode int
+
+const (
+	// UnwrapSingular will make the fields of singular jsonpaths, like
+	// `$.user.login`, a single value and the fields of any other jsonpath an
+	// array, even if it only has one result.
+	UnwrapSingular UnwrapMode = iota
+
+	// UnwrapSingle will make a field a single value whenever its jsonpath has
+	// exactly one result, and an array when it has more.
+	UnwrapSingle
+
+	// UnwrapNever will make every field an array of its results.
+	UnwrapNever
+)
+
+type (
+	// Projection builds new json objects out of the results of named
+	// jsonpaths, for example to reshape documents. The json is only parsed
+	// once for all of the fields. A Projection is safe for concurrent use.
+	Projection struct {
+		set      *QuerySet
+		fields   []projectedField
+		unwrap   UnwrapMode
+		defaults map[string]interface{}
+		options  []Option
+	}
+
+	projectedField struct {
+		name     string
+		singular bool
+
+		// fallback is the json of the field's default, it is nil if the field
+		// does not have one.
+		fallback json.RawMessage
+	}
+
+	// ProjectionOption changes how a Projection builds its objects.
+	ProjectionOption func(p *Projection)
+)
+
+// WithUnwrap will change when fields are a single value instead of an array.
+// By default only the fields of singular jsonpaths are single values.
+func WithUnwrap(mode UnwrapMode) ProjectionOption {
+	return func(p *Projection) {
+		p.unwrap = mode
+	}
+}
+
+// WithDefault will give the field a value to use when its jsonpath does not
+// match anything. Without a default, single value fields that do not match
+// anything are left out of the object and array fields are empty arrays.
+func WithDefault(field string, value interface{}) ProjectionOption {
+	return func(p *Projection) {
+		p.defaults[field] = value
+	}
+}
+
+// WithEvaluatorOptions will apply the options to every jsonpath of the
+// Projection.
+func WithEvaluatorOptions(options ...Option) ProjectionOption {
+	return func(p *Projection) {
+		p.options = append(p.options, options...)
+	}
+}
+
+// Project will build a new json object with a field for each of the provided
+// jsonpaths, which are keyed by the name of their field. For example the
+// fields {"name": "$.user.login", "emails": "$.user.emails[*].address"}
+// produce {"emails":["a@example.com"],"name":"alice"}. If the same fields are
+// used many times then a Projection should be created with NewProjection
+// instead.
+func Project(data []byte, fields map[string]string, options ...ProjectionOption) ([]byte, error) {
+	projection, err := NewProjection(fields, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return projection.Project(data)
+}
+
+// NewProjection will compile the jsonpath of each of the fields, which are
+// keyed by the name of their field. An error is returned if any of the
+// jsonpaths are not valid or if a default is given for a field that does not
+// exist.
+func NewProjection(fields map[string]string, options ...ProjectionOption) (*Projection, error) {
+	projection := &Projection{
+		defaults: map[string]interface{}{},
+	}
+	for _, option := range options {
+		option(projection)
+	}
+
+	set, err := NewQuerySet(fields, projection.options...)
+	if err != nil {
+		return nil, err
+	}
+	projection.set = set
+
+	for name, path := range fields {
+		parsed, err := Parse(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compile query '%s'", name)
+		}
+
+		// A function always has a single result.
+		field := projectedField{
+			name:     name,
+			singular: parsed.IsSingular() || parsed.Function != "",
+		}
+		if value, ok := projection.defaults[name]; ok {
+			if field.fallback, err = json.Marshal(value); err != nil {
+				return nil, errors.Wrapf(err, "failed to marshal default of field '%s'", name)
+			}
+		}
+
+		projection.fields = append(projection.fields, field)
+	}
+
+	for name := range projection.defaults {
+		if _, ok := fields[name]; !ok {
+			return nil, errors.Errorf("default given for unknown field '%s'", name)
+		}
+	}
+
+	// The fields are written in order so that the objects are consistent.
+	sort.Slice(projection.fields, func(i, j int) bool {
+		return projection.fields[i].name < projection.fields[j].name
+	})
+
+	return projection, nil
+}
+
+// Project will evaluate the fields against the provided json and return the
+// new json object. Values are copied from the provided json as they are, so
+// numbers keep their formatting. When the fields are evaluated with a Decoder
+// the values are encoded as json instead.
+func (p *Projection) Project(data []byte) ([]byte, error) {
+	results, err := p.set.rawMatches(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	written := 0
+	for _, field := range p.fields {
+		value, ok := p.value(field, results[field.name])
+		if !ok {
+			continue
+		}
+
+		if written > 0 {
+			buffer.WriteByte(',')
+		}
+		written++
+
+		name, _ := json.Marshal(field.name)
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		if err := json.Compact(&buffer, value); err != nil {
+			return nil, errors.Wrapf(err, "failed to write field '%s'", field.name)
+		}
+	}
+	buffer.WriteByte('}')
+
+	return buffer.Bytes(), nil
+}
+
+// value returns the json of the field for its results, or false if the field
+// should be left out of the object.
+func (p *Projection) value(field projectedField, matches []json.RawMessage) (json.RawMessage, bool) {
+	if len(matches) == 0 && field.fallback != nil {
+		return field.fallback, true
+	}
+
+	array := p.unwrap == UnwrapNever ||
+		(p.unwrap == UnwrapSingular && !field.singular) ||
+		(p.unwrap == UnwrapSingle && len(matches) > 1)
+	switch {
+	case array:
+		return rawArray(matches), true
+	case len(matches) == 0:
+		return nil, false
+	default:
+		return matches[0], true
+	}
+}
+
+// rawArray returns the json array of the values.
+func rawArray(values []json.RawMessage) json.RawMessage {
+	array := []byte{'['}
+	for i, value := range values {
+		if i > 0 {
+			array = append(array, ',')
+		}
+		array = append(array, value...)
+	}
+
+	return append(array, ']')
+}
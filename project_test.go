@@ -0,0 +1,121 @@
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const UserJson = `{
+	"user": {
+		"login": "alice",
+		"id": 12345678901234567890,
+		"emails": [
+			{"address": "alice@example.com", "primary": true}
+		],
+		"repos": [{"name": "a"}, {"name": "b"}]
+	}
+}`
+
+func TestProject(t *testing.T) {
+	fields := map[string]string{
+		"name":   "$.user.login",
+		"id":     "$.user.id",
+		"emails": "$.user.emails[*].address",
+		"repos":  "$.user.repos[*].name",
+		"team":   "$.user.team",
+		"orgs":   "$.user.orgs[*]",
+	}
+
+	t.Run("unwrap singular", func(t *testing.T) {
+		result, err := Project([]byte(UserJson), fields)
+		require.NoError(t, err)
+		assert.Equal(t, `{"emails":["alice@example.com"],"id":12345678901234567890,"name":"alice","orgs":[],"repos":["a","b"]}`, string(result))
+	})
+
+	t.Run("unwrap single", func(t *testing.T) {
+		result, err := Project([]byte(UserJson), fields, WithUnwrap(UnwrapSingle))
+		require.NoError(t, err)
+		assert.Equal(t, `{"emails":"alice@example.com","id":12345678901234567890,"name":"alice","repos":["a","b"]}`, string(result))
+	})
+
+	t.Run("unwrap never", func(t *testing.T) {
+		result, err := Project([]byte(UserJson), fields, WithUnwrap(UnwrapNever))
+		require.NoError(t, err)
+		assert.Equal(t, `{"emails":["alice@example.com"],"id":[12345678901234567890],"name":["alice"],"orgs":[],"repos":["a","b"],"team":[]}`, string(result))
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		result, err := Project([]byte(UserJson), fields,
+			WithDefault("team", "none"),
+			WithDefault("orgs", nil),
+			WithDefault("name", "unknown"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, `{"emails":["alice@example.com"],"id":12345678901234567890,"name":"alice","orgs":null,"repos":["a","b"],"team":"none"}`, string(result))
+	})
+
+	t.Run("defaults for scalars", func(t *testing.T) {
+		// A scalar where an array is expected does not match, so the default
+		// is used instead.
+		result, err := Project([]byte(`{"user": {"login": "alice", "emails": "alice@example.com"}}`), map[string]string{
+			"name":  "$.user.login",
+			"email": "$.user.emails[0]",
+			"first": "$.user.emails[:1]",
+		}, WithDefault("email", ""), WithDefault("first", nil))
+		require.NoError(t, err)
+		assert.Equal(t, `{"email":"","first":null,"name":"alice"}`, string(result))
+	})
+
+	t.Run("objects", func(t *testing.T) {
+		result, err := Project([]byte(UserJson), map[string]string{
+			"primary": "$.user.emails[?@.primary]",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `{"primary":[{"address":"alice@example.com","primary":true}]}`, string(result))
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		projection, err := NewProjection(map[string]string{"name": "$.name"})
+		require.NoError(t, err)
+
+		for _, name := range []string{"a", "b"} {
+			result, err := projection.Project([]byte(`{"name": "` + name + `"}`))
+			require.NoError(t, err)
+			assert.Equal(t, `{"name":"`+name+`"}`, string(result))
+		}
+
+		result, err := projection.Project([]byte(`{}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{}`, string(result))
+	})
+
+	t.Run("evaluator options", func(t *testing.T) {
+		_, err := Project([]byte(UserJson), fields, WithEvaluatorOptions(WithLimits(Limits{MaxResults: 1})))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrLimitExceeded), err.Error())
+	})
+
+	t.Run("decoder", func(t *testing.T) {
+		data := []byte("user:\n  login: alice\n  repos: [{name: a}, {name: b}]\n")
+		result, err := Project(data, fields, WithEvaluatorOptions(WithDecoder(YAMLDecoder)))
+		require.NoError(t, err)
+		assert.Equal(t, `{"emails":[],"name":"alice","orgs":[],"repos":["a","b"]}`, string(result))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewProjection(map[string]string{"name": "$.["})
+		assert.Error(t, err)
+
+		_, err = NewProjection(map[string]string{"name": "$.name"}, WithDefault("missing", 1))
+		assert.Error(t, err)
+
+		_, err = NewProjection(map[string]string{"name": "$.name"}, WithDefault("name", func() {}))
+		assert.Error(t, err)
+
+		_, err = Project([]byte(`{`), map[string]string{"name": "$.name"})
+		assert.Error(t, err)
+	})
+}
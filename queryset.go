@@ -0,0 +1,232 @@
+package jsonpath
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// QuerySet evaluates many named jsonpaths against the same json while only
+	// parsing the json once. The jsonpaths are compiled into a tree so that
+	// any segments that they start with are only evaluated once, for example
+	// `$.user.name` and `$.user.email` will share `$.user`. A QuerySet is
+	// safe for concurrent use.
+	QuerySet struct {
+		root querySegment
+
+		// settings holds the options of the QuerySet, it is never evaluated.
+		settings Evaluator
+
+		// functions holds the function at the end of each jsonpath that has
+		// one, keyed by its name.
+		functions map[string]*compiledFunction
+	}
+
+	// querySegment is a node in the tree of a QuerySet. It has the actions of
+	// a single segment along with the names of the queries that end with it.
+	querySegment struct {
+		path     string
+		actions  []jsonAction
+		names    []string
+		children []*querySegment
+	}
+)
+
+// NewQuerySet will compile each of the provided jsonpaths, which are keyed by
+// the name that their results will be returned with. An error is returned if
+// any of the jsonpaths are not valid. The options apply to every jsonpath.
+func NewQuerySet(paths map[string]string, options ...Option) (*QuerySet, error) {
+	set := &QuerySet{
+		root: querySegment{
+			path: "$",
+		},
+		functions: map[string]*compiledFunction{},
+	}
+	set.settings.apply(options)
+
+	for name, path := range paths {
+		if err := set.add(name, path); err != nil {
+			return nil, errors.Wrapf(err, "failed to compile query '%s'", name)
+		}
+	}
+
+	return set, nil
+}
+
+// add will compile the jsonpath and add its segments to the tree.
+func (q *QuerySet) add(name, path string) error {
+	if err := q.settings.limits.checkPathLength(path); err != nil {
+		return err
+	}
+
+	parsed, err := parseLimited(path, q.settings.limits)
+	if err != nil {
+		return err
+	}
+
+	if err := q.settings.limits.checkPath(parsed); err != nil {
+		return err
+	}
+
+	// Segments are keyed by their normalized form so that segments which are
+	// written differently but have the same meaning are shared.
+	formatter := pathFormatter{
+		notation:  BracketNotation,
+		normalize: true,
+	}
+
+	current := &q.root
+	for _, segment := range parsed.Segments {
+		key := current.path + formatter.segment(segment)
+
+		var next *querySegment
+		for _, child := range current.children {
+			if child.path == key {
+				next = child
+				break
+			}
+		}
+
+		if next == nil {
+			actions, err := compileSegments([]Segment{segment})
+			if err != nil {
+				return err
+			}
+
+			next = &querySegment{
+				path:    key,
+				actions: actions,
+			}
+			current.children = append(current.children, next)
+		}
+
+		current = next
+	}
+
+	current.names = append(current.names, name)
+
+	if parsed.Function != "" {
+		q.functions[name] = &compiledFunction{
+			function: parsed.Function,
+			singular: parsed.IsSingular(),
+		}
+	}
+
+	return nil
+}
+
+// Evaluate will parse the json and evaluate every jsonpath in the set against
+// it. The results of each jsonpath are returned by its name, jsonpaths that
+// do not match anything have an empty result. An error is returned if the
+// json is invalid or if any of the jsonpaths fail to evaluate.
+func (q *QuerySet) Evaluate(data []byte) (map[string][]interface{}, error) {
+	return q.EvaluateContext(context.Background(), data)
+}
+
+// EvaluateContext is like Evaluate but will stop evaluating and return the
+// context's error as soon as the context is done.
+func (q *QuerySet) EvaluateContext(ctx context.Context, data []byte) (map[string][]interface{}, error) {
+	nodes, err := q.run(ctx, data, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]interface{}, len(nodes))
+	for name, matches := range nodes {
+		results[name] = valuesOf(matches)
+	}
+
+	return results, nil
+}
+
+// EvaluateRaw is like Evaluate but returns the raw json of each result
+// instead of decoding it, the same as Evaluator.EvaluateRaw.
+func (q *QuerySet) EvaluateRaw(data []byte) (map[string][]json.RawMessage, error) {
+	if err := q.settings.checkJSONInput("raw results"); err != nil {
+		return nil, err
+	}
+
+	// Track where each result is so that it can be found in the raw json.
+	nodes, err := q.run(context.Background(), data, rootLocation())
+	if err != nil {
+		return nil, err
+	}
+
+	// All of the results are found with a single pass over the json. The
+	// results of functions are not in the json so they are encoded instead.
+	results := make(map[string][]json.RawMessage, len(nodes))
+	names := make([]string, 0, len(nodes))
+	locations := make([]*location, 0)
+	for name, matches := range nodes {
+		if _, ok := q.functions[name]; ok {
+			if results[name], err = encodeRaw(valuesOf(matches)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		names = append(names, name)
+		for _, match := range matches {
+			locations = append(locations, match.location)
+		}
+	}
+
+	raw := locateRaw(data, locations)
+	for _, name := range names {
+		count := len(nodes[name])
+		results[name], raw = raw[:count:count], raw[count:]
+	}
+
+	return results, nil
+}
+
+// run will parse the json and evaluate every query, returning the nodes that
+// each of them selected.
+func (q *QuerySet) run(ctx context.Context, data []byte, root *location) (map[string][]locatedNode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	node, err := q.settings.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[string][]locatedNode{}
+	evalCtx := q.settings.newContext(locatedNode{value: node, location: root}, newCancellation(ctx))
+	if err := q.root.evaluate(evalCtx, results); err != nil {
+		return nil, err
+	}
+
+	for name, function := range q.functions {
+		results[name] = function.apply(results[name], q.settings.numbers)
+	}
+
+	return results, nil
+}
+
+// evaluate will store the results of the queries that end with this segment
+// and then evaluate each of the segments after it.
+func (s *querySegment) evaluate(ctx *evalContext, results map[string][]locatedNode) error {
+	if len(s.names) > 0 {
+		nodes, _ := nodesOf(ctx.data)
+		for _, name := range s.names {
+			results[name] = nodes
+		}
+	}
+
+	for _, child := range s.children {
+		childCtx, err := executeActions(ctx, child.actions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to evaluate %s", child.path)
+		}
+
+		if err := child.evaluate(childCtx, results); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package jsonpath
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySet(t *testing.T) {
+	paths := map[string]string{
+		"authors":  "$.store.book[*].author",
+		"titles":   "$.store.book[*].title",
+		"first":    "$['store'].book[0:1:1].title",
+		"cheap":    "$.store.book[?@.price < $.expensive].title",
+		"bicycle":  "$.store.bicycle.color",
+		"prices":   "$..price",
+		"root":     "$",
+		"missing":  "$.store.missing",
+		"same":     "$.store.bicycle.color",
+		"absolute": "$.store.book[?@.price > $.store.bicycle.price].author",
+	}
+
+	t.Run("same results as evaluators", func(t *testing.T) {
+		set, err := NewQuerySet(paths)
+		require.NoError(t, err)
+
+		results, err := set.Evaluate([]byte(StoreJson))
+		require.NoError(t, err)
+		require.Len(t, results, len(paths))
+
+		for name, path := range paths {
+			expected, err := MustCompile(path).Evaluate([]byte(StoreJson))
+			require.NoError(t, err, path)
+			assert.Equal(t, expected, results[name], name)
+		}
+
+		assert.NotNil(t, results["missing"])
+		assert.Empty(t, results["missing"])
+	})
+
+	t.Run("shares segments", func(t *testing.T) {
+		set, err := NewQuerySet(paths)
+		require.NoError(t, err)
+
+		// The paths only start with two different segments.
+		assert.Len(t, set.root.children, 2)
+
+		// The wildcard, slice and filter segments all follow the same book
+		// segment, and the slice is shared even though it is written
+		// differently.
+		book := findQuerySegment(&set.root, "$['store']['book']")
+		require.NotNil(t, book)
+		assert.Len(t, book.children, 4)
+
+		color := findQuerySegment(&set.root, "$['store']['bicycle']['color']")
+		require.NotNil(t, color)
+		assert.ElementsMatch(t, []string{"bicycle", "same"}, color.names)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		set, err := NewQuerySet(paths)
+		require.NoError(t, err)
+
+		results, err := set.EvaluateRaw([]byte(StoreJson))
+		require.NoError(t, err)
+		require.Len(t, results, len(paths))
+
+		for name, path := range paths {
+			expected, err := MustCompile(path).EvaluateRaw([]byte(StoreJson))
+			require.NoError(t, err, path)
+			assert.Equal(t, expected, results[name], name)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		set, err := NewQuerySet(map[string]string{
+			"id": "$.orders[0].id",
+		}, WithNumberMode(JSONNumbers))
+		require.NoError(t, err)
+
+		results, err := set.Evaluate([]byte(OrdersJson))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{json.Number("1234567890123456789")}, results["id"])
+
+		_, err = NewQuerySet(map[string]string{
+			"long": "$.a.b.c.d",
+		}, WithLimits(Limits{MaxSelectors: 2}))
+		assert.True(t, errors.Is(err, ErrLimitExceeded))
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		set, err := NewQuerySet(map[string]string{
+			"bad": "$.store[",
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to compile query 'bad'")
+		assert.Nil(t, set)
+	})
+
+	t.Run("mixed shapes", func(t *testing.T) {
+		// Some of the items have null or scalar values where the other items
+		// have arrays, which must not fail any of the queries.
+		data := []byte(`{"items": [
+			{"id": "a", "tags": ["x", "y"], "sizes": [1, 2]},
+			{"id": "b", "tags": null, "sizes": 3},
+			{"id": "c", "tags": "z", "sizes": {"0": 4}}
+		]}`)
+
+		set, err := NewQuerySet(map[string]string{
+			"ids":   "$.items[*].id",
+			"first": "$.items[*].tags[0]",
+			"last":  "$.items[*].tags[-1:]",
+			"sizes": "$.items[*].sizes[0,'0']",
+			"b":     "$.items[1].tags[0]",
+		})
+		require.NoError(t, err)
+
+		results, err := set.Evaluate(data)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]interface{}{
+			"ids":   {"a", "b", "c"},
+			"first": {"x"},
+			"last":  {"y"},
+			"sizes": {1.0, 4.0},
+			"b":     {},
+		}, results)
+	})
+
+	t.Run("evaluation error", func(t *testing.T) {
+		set, err := NewQuerySet(map[string]string{
+			"books": "$.store.book[*]",
+		}, WithLimits(Limits{MaxResults: 1}))
+		require.NoError(t, err)
+
+		results, err := set.Evaluate([]byte(StoreJson))
+		assert.EqualError(t, err, "failed to evaluate $['store']['book'][*]: results limit of 1 exceeded")
+		assert.Nil(t, results)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		set, err := NewQuerySet(paths)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results, err := set.EvaluateContext(ctx, []byte(StoreJson))
+		assert.Equal(t, context.Canceled, err)
+		assert.Nil(t, results)
+	})
+}
+
+// findQuerySegment will return the segment of the tree with the provided path.
+func findQuerySegment(segment *querySegment, path string) *querySegment {
+	if segment.path == path {
+		return segment
+	}
+
+	for _, child := range segment.children {
+		if found := findQuerySegment(child, path); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,561 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+type (
+	// rawPath is a compiled path that can be evaluated by scanning the raw
+	// json instead of parsing all of it. Only paths that are made up of child
+	// segments selecting names, indexes or a wildcard can be scanned.
+	rawPath []rawSegment
+
+	// rawSegment is a single segment of a rawPath. Only one of names, indexes
+	// or wildcard will be set.
+	rawSegment struct {
+		names    []string
+		indexes  []int
+		wildcard bool
+	}
+
+	// jsonScanner moves through raw json without decoding it. It assumes that
+	// the json is valid, so it must be validated before it is scanned.
+	jsonScanner struct {
+		data   []byte
+		offset int
+		cancel *cancellation
+	}
+
+	// rawMember is the key and position of the value of an object member.
+	rawMember struct {
+		key    string
+		offset int
+	}
+
+	// rawLocator is a tree of the locations that need to be found within the
+	// raw json. Each locator is a single member or element, results are the
+	// indexes of the results that are at that location.
+	rawLocator struct {
+		names   map[string]*rawLocator
+		indexes map[int]*rawLocator
+		results []int
+
+		// offset is set to the start of the locator's value while its parent
+		// is being scanned.
+		offset int
+		found  bool
+	}
+)
+
+// newRawPath will return the rawPath for the provided path. If the path uses
+// anything that cannot be scanned then false is returned and the path must be
+// evaluated normally.
+func newRawPath(path *Path) (rawPath, bool) {
+	raw := make(rawPath, 0, len(path.Segments))
+	for _, segment := range path.Segments {
+		child, ok := segment.(ChildSegment)
+		if !ok || len(child) == 0 {
+			return nil, false
+		}
+
+		var rawSegment rawSegment
+		for _, selector := range child {
+			switch s := selector.(type) {
+			case NameSelector:
+				rawSegment.names = append(rawSegment.names, string(s))
+			case IndexSelector:
+				rawSegment.indexes = append(rawSegment.indexes, int(s))
+			case WildcardSelector:
+				rawSegment.wildcard = true
+			default:
+				return nil, false
+			}
+		}
+
+		// Segments that mix different kinds of selectors are left to the
+		// unionAction.
+		switch len(child) {
+		case len(rawSegment.names), len(rawSegment.indexes):
+		default:
+			if !rawSegment.wildcard || len(child) > 1 {
+				return nil, false
+			}
+		}
+
+		raw = append(raw, rawSegment)
+	}
+
+	return raw, true
+}
+
+// scan will find the raw json of every value that the path selects. The
+// returned values are slices of data, nothing is copied.
+func (r rawPath) scan(data []byte, cancel *cancellation) ([]json.RawMessage, error) {
+	matches := make([]json.RawMessage, 0)
+	err := r.stream(data, cancel, func(match json.RawMessage) (bool, error) {
+		matches = append(matches, match)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// stream will call yield with the raw json of each value that the path
+// selects as it is found. Scanning stops as soon as yield returns false or an
+// error, or when the cancellation is done.
+func (r rawPath) stream(data []byte, cancel *cancellation, yield func(match json.RawMessage) (bool, error)) error {
+	if !json.Valid(data) {
+		// Let the json package describe what is wrong with the input.
+		_, err := parseJson(data)
+		return err
+	}
+
+	scanner := &jsonScanner{
+		data:   data,
+		cancel: cancel,
+	}
+	_, err := scanner.match(r, yield)
+
+	return err
+}
+
+// locateRaw will find the raw json at each of the locations. The json must
+// be valid and every location must exist within it.
+func locateRaw(data []byte, locations []*location) []json.RawMessage {
+	root := &rawLocator{}
+	for i, location := range locations {
+		locator := root
+		for _, key := range location.keys() {
+			locator = locator.child(key)
+		}
+
+		locator.results = append(locator.results, i)
+	}
+
+	results := make([]json.RawMessage, len(locations))
+	scanner := &jsonScanner{
+		data: data,
+	}
+	scanner.locate(root, results)
+
+	return results
+}
+
+// child returns the locator for the member or element with the provided key,
+// creating it if it does not exist yet.
+func (r *rawLocator) child(key interface{}) *rawLocator {
+	var child *rawLocator
+	var ok bool
+	switch k := key.(type) {
+	case string:
+		if child, ok = r.names[k]; !ok {
+			if r.names == nil {
+				r.names = map[string]*rawLocator{}
+			}
+			child = &rawLocator{}
+			r.names[k] = child
+		}
+	case int:
+		if child, ok = r.indexes[k]; !ok {
+			if r.indexes == nil {
+				r.indexes = map[int]*rawLocator{}
+			}
+			child = &rawLocator{}
+			r.indexes[k] = child
+		}
+	}
+
+	return child
+}
+
+// locate will set the results of the locator and all of its children from the
+// value at the current offset. The offset is moved to the end of the value.
+func (s *jsonScanner) locate(locator *rawLocator, results []json.RawMessage) {
+	s.skipWhitespace()
+	start := s.offset
+
+	children := make([]*rawLocator, 0, len(locator.names)+len(locator.indexes))
+	switch {
+	case len(locator.names) > 0 && s.data[s.offset] == '{':
+		s.scanMembers(func(key []byte, offset int) {
+			if child, ok := locator.names[memberName(key)]; ok {
+				// If there are duplicate keys then the last one is used.
+				if !child.found {
+					children = append(children, child)
+				}
+				child.offset, child.found = offset, true
+			}
+		})
+	case len(locator.indexes) > 0 && s.data[s.offset] == '[':
+		index := 0
+		s.scanElements(func(offset int) {
+			if child, ok := locator.indexes[index]; ok {
+				children = append(children, child)
+				child.offset, child.found = offset, true
+			}
+			index++
+		})
+	default:
+		s.skipValue()
+	}
+
+	end := s.offset
+	for _, child := range children {
+		s.offset = child.offset
+		s.locate(child, results)
+	}
+	s.offset = end
+
+	for _, result := range locator.results {
+		results[result] = s.data[start:end]
+	}
+}
+
+// match will call yield with every value that the path selects from the value
+// at the current offset. The offset is moved to the end of the value. If
+// yield returns false then matching stops and false is returned.
+func (s *jsonScanner) match(path rawPath, yield func(match json.RawMessage) (bool, error)) (bool, error) {
+	if err := s.cancel.check(); err != nil {
+		return false, err
+	}
+
+	s.skipWhitespace()
+	if len(path) == 0 {
+		start := s.offset
+		s.skipValue()
+		return yield(s.data[start:s.offset])
+	}
+
+	segment, rest := path[0], path[1:]
+	switch {
+	case segment.wildcard:
+		return s.matchWildcard(rest, yield)
+	case len(segment.indexes) > 0:
+		return s.matchIndexes(segment.indexes, rest, yield)
+	default:
+		return s.matchNames(segment.names, rest, yield)
+	}
+}
+
+// matchNames will match the rest of the path against the members of the
+// object with the provided names. If there are duplicate keys in the object
+// then the last one is used, the same as when the json is parsed.
+func (s *jsonScanner) matchNames(names []string, rest rawPath, yield func(match json.RawMessage) (bool, error)) (bool, error) {
+	if s.data[s.offset] != '{' {
+		s.skipValue()
+		return true, nil
+	}
+
+	// Most paths only select a single name, avoid allocating for those.
+	var buffer [4]int
+	positions := buffer[:0]
+	if len(names) > len(buffer) {
+		positions = make([]int, 0, len(names))
+	}
+	for range names {
+		positions = append(positions, -1)
+	}
+
+	var err error
+	s.scanMembers(func(key []byte, offset int) {
+		if err == nil {
+			err = s.cancel.check()
+		}
+
+		for i, name := range names {
+			if keyEquals(key, name) {
+				positions[i] = offset
+			}
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	end := s.offset
+	for _, position := range positions {
+		if position < 0 {
+			continue
+		}
+
+		s.offset = position
+		if ok, err := s.match(rest, yield); !ok || err != nil {
+			return ok, err
+		}
+	}
+	s.offset = end
+
+	return true, nil
+}
+
+// matchIndexes will match the rest of the path against the elements of the
+// array at the provided indexes. Any other kind of value does not match.
+func (s *jsonScanner) matchIndexes(indexes []int, rest rawPath, yield func(match json.RawMessage) (bool, error)) (bool, error) {
+	if s.data[s.offset] != '[' {
+		s.skipValue()
+		return true, nil
+	}
+
+	start := s.offset
+	length := 0
+	var err error
+	s.scanElements(func(int) {
+		if err == nil {
+			err = s.cancel.check()
+		}
+
+		length++
+	})
+	if err != nil {
+		return false, err
+	}
+	end := s.offset
+
+	for _, index := range indexes {
+		if index < 0 {
+			index += length
+		}
+
+		if index < 0 || index >= length {
+			continue
+		}
+
+		s.offset = start
+		s.seekElement(index)
+		if ok, err := s.match(rest, yield); !ok || err != nil {
+			return ok, err
+		}
+	}
+	s.offset = end
+
+	return true, nil
+}
+
+// matchWildcard will match the rest of the path against every element of an
+// array or every member of an object. Members are matched in the order of
+// their keys, the same as when the json is parsed.
+func (s *jsonScanner) matchWildcard(rest rawPath, yield func(match json.RawMessage) (bool, error)) (bool, error) {
+	var offsets []int
+	var err error
+	switch s.data[s.offset] {
+	case '[':
+		s.scanElements(func(offset int) {
+			if err == nil {
+				err = s.cancel.check()
+			}
+
+			offsets = append(offsets, offset)
+		})
+	case '{':
+		members := make([]rawMember, 0)
+		s.scanMembers(func(key []byte, offset int) {
+			if err == nil {
+				err = s.cancel.check()
+			}
+
+			members = append(members, rawMember{
+				key:    decodeKey(key),
+				offset: offset,
+			})
+		})
+
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].key < members[j].key
+		})
+
+		for i, member := range members {
+			// Only the last of any duplicate keys is kept.
+			if i+1 < len(members) && members[i+1].key == member.key {
+				continue
+			}
+
+			offsets = append(offsets, member.offset)
+		}
+	default:
+		s.skipValue()
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	end := s.offset
+	for _, offset := range offsets {
+		s.offset = offset
+		if ok, err := s.match(rest, yield); !ok || err != nil {
+			return ok, err
+		}
+	}
+	s.offset = end
+
+	return true, nil
+}
+
+// scanMembers will call fn with the raw key and value offset of each member of
+// the object at the current offset. The offset is moved to the end of the
+// object.
+func (s *jsonScanner) scanMembers(fn func(key []byte, offset int)) {
+	s.offset++ // Consume the {
+	for {
+		s.skipWhitespace()
+		if s.data[s.offset] == '}' {
+			s.offset++
+			return
+		}
+
+		if s.data[s.offset] == ',' {
+			s.offset++
+			s.skipWhitespace()
+		}
+
+		start := s.offset
+		s.skipString()
+		key := s.data[start:s.offset]
+
+		s.skipWhitespace()
+		s.offset++ // Consume the :
+		s.skipWhitespace()
+
+		fn(key, s.offset)
+		s.skipValue()
+	}
+}
+
+// scanElements will call fn with the offset of each element of the array at
+// the current offset. The offset is moved to the end of the array.
+func (s *jsonScanner) scanElements(fn func(offset int)) {
+	s.offset++ // Consume the [
+	for {
+		s.skipWhitespace()
+		if s.data[s.offset] == ']' {
+			s.offset++
+			return
+		}
+
+		if s.data[s.offset] == ',' {
+			s.offset++
+			s.skipWhitespace()
+		}
+
+		fn(s.offset)
+		s.skipValue()
+	}
+}
+
+// seekElement will move the offset from the start of an array to the element
+// at the provided index. The index must be within the bounds of the array.
+func (s *jsonScanner) seekElement(index int) {
+	s.offset++ // Consume the [
+	for i := 0; i < index; i++ {
+		s.skipWhitespace()
+		s.skipValue()
+		s.skipWhitespace()
+		s.offset++ // Consume the ,
+	}
+	s.skipWhitespace()
+}
+
+func (s *jsonScanner) skipWhitespace() {
+	for s.offset < len(s.data) {
+		switch s.data[s.offset] {
+		case ' ', '\t', '\n', '\r':
+			s.offset++
+		default:
+			return
+		}
+	}
+}
+
+// skipValue will move the offset to the end of the value at the current
+// offset.
+func (s *jsonScanner) skipValue() {
+	switch s.data[s.offset] {
+	case '"':
+		s.skipString()
+	case '{', '[':
+		depth := 0
+		for s.offset < len(s.data) {
+			switch s.data[s.offset] {
+			case '"':
+				s.skipString()
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+
+			s.offset++
+			if depth == 0 {
+				return
+			}
+		}
+	default:
+		// Numbers, booleans and null end at the next delimiter.
+		for s.offset < len(s.data) {
+			switch s.data[s.offset] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return
+			}
+
+			s.offset++
+		}
+	}
+}
+
+// skipString will move the offset past the end of the string at the current
+// offset, including the closing quote.
+func (s *jsonScanner) skipString() {
+	s.offset++ // Consume the opening quote.
+	for s.offset < len(s.data) {
+		switch s.data[s.offset] {
+		case '\\':
+			s.offset += 2
+		case '"':
+			s.offset++
+			return
+		default:
+			s.offset++
+		}
+	}
+}
+
+// keyEquals returns true if the raw quoted key is the same as the name. Keys
+// are only decoded if they contain escapes.
+func keyEquals(key []byte, name string) bool {
+	inner := key[1 : len(key)-1]
+	if bytes.IndexByte(inner, '\\') < 0 {
+		return string(inner) == name
+	}
+
+	return decodeKey(key) == name
+}
+
+// memberName returns the name of an object member from its raw quoted key. It
+// only allocates if the key contains escapes, unless the result escapes.
+func memberName(key []byte) string {
+	inner := key[1 : len(key)-1]
+	if bytes.IndexByte(inner, '\\') < 0 {
+		return string(inner)
+	}
+
+	return decodeKey(key)
+}
+
+// decodeKey will return the string value of a raw quoted key.
+func decodeKey(key []byte) string {
+	inner := key[1 : len(key)-1]
+	if bytes.IndexByte(inner, '\\') < 0 {
+		return string(inner)
+	}
+
+	var decoded string
+	_ = json.Unmarshal(key, &decoded) // The key has already been validated.
+
+	return decoded
+}
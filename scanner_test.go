@@ -0,0 +1,190 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluateWithoutScanning will evaluate the path by parsing the json, even if
+// the path could be scanned.
+func evaluateWithoutScanning(t *testing.T, path string, data []byte) ([]interface{}, error) {
+	eval, err := NewEvaluator(path)
+	require.NoError(t, err, path)
+
+	node, err := parseJson(data)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := eval.run(locatedNode{value: node}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return valuesOf(nodes), nil
+}
+
+func TestNewRawPath(t *testing.T) {
+	paths := map[string]bool{
+		"$":                   true,
+		"$.a.b":               true,
+		"$['a','b'][0,-1]":    true,
+		"$.a[*].b":            true,
+		"$.*":                 true,
+		"$..a":                false,
+		"$[0:2]":              false,
+		"$[?@.a]":             false,
+		"$[0,'a']":            false,
+		"$[*,*]":              false,
+		"$.a[*,0]":            false,
+		"$.phoneNumbers[0,1]": true,
+	}
+
+	for input, expected := range paths {
+		path, err := Parse(input)
+		require.NoError(t, err, input)
+
+		_, ok := newRawPath(path)
+		assert.Equal(t, expected, ok, input)
+	}
+}
+
+func TestRawPath_Scan(t *testing.T) {
+	documents := []string{
+		TestJson,
+		StoreJson,
+		`{"a": 1, "b": {"c": [1, 2, {"d": null}]}, "a": 2}`,
+		`{"a": "escaped", "b\"c": "quote", "z": {"y": [true, false]}, "e": {}}`,
+		`[[1, 2, 3], [], [ 4 , "5" ], {"a": [6]}]`,
+		` "just a string" `,
+		`[{"a": "x"}, {"a": "y"}, {"b": "z"}]`,
+		`{"nested": {"array": [ {"k": -1.5e3}, {"k": "]}\\""} ]}}`,
+	}
+
+	paths := []string{
+		"$",
+		"$.a",
+		"$.b.c[2].d",
+		"$.b.c[-1]",
+		"$.b.c[-4]",
+		"$['a','b']",
+		"$.*",
+		"$[*]",
+		"$[*].a",
+		"$[*][*]",
+		"$[0,-1,5]",
+		"$['b\"c']",
+		"$.z.y[1]",
+		"$.e.*",
+		"$.store.book[*].author",
+		"$.store.*",
+		"$.phoneNumbers[0,1].type",
+		"$.phoneNumbers.type",
+		"$.nested.array[*].k",
+		"$.missing[0]",
+		"$.a[0]",
+		"$.b[0,-1]",
+		"$[*][0]",
+		"$.*[0].author",
+		"$.store.bicycle[0]",
+	}
+
+	for _, document := range documents {
+		for _, path := range paths {
+			expected, expectedErr := evaluateWithoutScanning(t, path, []byte(document))
+
+			parsed, err := Parse(path)
+			require.NoError(t, err)
+			raw, ok := newRawPath(parsed)
+			require.True(t, ok, path)
+
+			eval, err := NewEvaluator(path)
+			require.NoError(t, err)
+			result, err := eval.scan([]byte(document), nil)
+
+			if expectedErr != nil {
+				assert.EqualError(t, err, expectedErr.Error(), "%s on %s", path, document)
+				continue
+			}
+
+			require.NoError(t, err, "%s on %s", path, document)
+			assert.Equal(t, expected, result, "%s on %s", path, document)
+
+			// Each match must be the exact bytes from the document.
+			matches, err := raw.scan([]byte(document), nil)
+			require.NoError(t, err)
+			for _, match := range matches {
+				assert.True(t, json.Valid(match), string(match))
+				assert.Contains(t, document, string(match))
+			}
+		}
+	}
+}
+
+func TestRawPath_ScanInvalid(t *testing.T) {
+	documents := []string{
+		`{"test:true}`,
+		`{"a": 1} trailing`,
+		`{"a": [1, 2}`,
+		``,
+		`{"a": tru}`,
+	}
+
+	for _, document := range documents {
+		_, expected := parseJson([]byte(document))
+		require.Error(t, expected)
+
+		result, err := Jsonpath([]byte(document), "$.a")
+		assert.EqualError(t, err, expected.Error(), document)
+		assert.Nil(t, result)
+	}
+}
+
+func TestRawPath_ScanAllocations(t *testing.T) {
+	var builder strings.Builder
+	builder.WriteString(`{"items": [`)
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(`{"id": 1, "name": "item", "tags": ["a", "b"], "nested": {"x": 1.5}}`)
+	}
+	builder.WriteString(`], "actor": {"login": "octocat"}}`)
+	data := []byte(builder.String())
+
+	path, err := Parse("$.actor.login")
+	require.NoError(t, err)
+	raw, ok := newRawPath(path)
+	require.True(t, ok)
+
+	allocations := testing.AllocsPerRun(10, func() {
+		matches, err := raw.scan(data, nil)
+		if err != nil || len(matches) != 1 {
+			t.Fatal("expected a single match")
+		}
+	})
+	assert.LessOrEqual(t, allocations, float64(2), "scanning should not allocate for skipped values")
+}
+
+func BenchmarkEvaluator_Evaluate(b *testing.B) {
+	data := []byte(StoreJson)
+	b.Run("scan", func(b *testing.B) {
+		eval := MustCompile("$.store.bicycle.color")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = eval.Evaluate(data)
+		}
+	})
+
+	b.Run("parse", func(b *testing.B) {
+		eval := MustCompile("$..bicycle.color")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = eval.Evaluate(data)
+		}
+	})
+}
@@ -2,6 +2,9 @@ package jsonpath
 
 import (
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
 
 	"github.com/pkg/errors"
 )
@@ -185,6 +188,19 @@ func (t *pathTokenizer) nextToken() (pathToken, error) {
 		}
 
 		return greaterThan, nil
+	case '&':
+		// Logical operators are always two characters, there is no single &.
+		if nextCharacter := t.scanAndPeek(); nextCharacter == '&' {
+			return t.consumeAndReturn(logicalAnd)
+		}
+
+		return nil, errors.Errorf("unexpected '&', expected '&&'")
+	case '|':
+		if nextCharacter := t.scanAndPeek(); nextCharacter == '|' {
+			return t.consumeAndReturn(logicalOr)
+		}
+
+		return nil, errors.Errorf("unexpected '|', expected '||'")
 	case ':':
 		return t.consumeAndReturn(colon)
 	case '?':
@@ -228,15 +244,20 @@ func (t *pathTokenizer) tokenizeNumber() (pathToken, error) {
 	str := t.path[startingIndex:t.offset]
 
 	if isDecimal {
-		decimal, err := strconv.ParseFloat(str, 64)
-		if err != nil {
+		// Decimals are only parsed to check them, they are kept as they were
+		// written so that they can be compared exactly.
+		if _, err := strconv.ParseFloat(str, 64); err != nil && !isRangeError(err) {
 			return nil, errors.Wrapf(err, "failed to parse '%s' as float", str)
 		}
 
-		return decimalToken(decimal), nil
+		return numberToken(str), nil
 	}
 
 	integer, err := strconv.ParseInt(str, 10, 64)
+	if isRangeError(err) {
+		return numberToken(str), nil
+	}
+
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse '%s' as integer", str)
 	}
@@ -244,6 +265,13 @@ func (t *pathTokenizer) tokenizeNumber() (pathToken, error) {
 	return integerToken(integer), nil
 }
 
+// isRangeError returns true if the number could not be parsed because it is
+// too large.
+func isRangeError(err error) bool {
+	numError, ok := err.(*strconv.NumError)
+	return ok && numError.Err == strconv.ErrRange
+}
+
 func (t *pathTokenizer) isNumericPart(character byte) bool {
 	switch character {
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '.':
@@ -257,40 +285,126 @@ func (t *pathTokenizer) tokenizeQuotedString(quote byte) (string, error) {
 	// Consume the first character, we are assuming that it is the quote char.
 	t.offset++
 
-	// Store the current index, we are just going to move the cursor forward
-	// until we find another quote character that is not escaped.
+	// Store the current index, if the string does not contain any escapes then
+	// we can just return a slice of the path.
 	startingIndex := t.offset
+	var builder *strings.Builder
 
-ScanLoop:
 	for {
 		character := t.scan()
 		switch character {
 		case 0:
 			return "", errors.Errorf("unexpected eof parsing string")
 		case quote:
-			// If there are two of the quotes in a row we want to consider that
-			// an escape.
+			// Two of the quotes in a row are still accepted as an escaped quote,
+			// this is how earlier versions escaped quotes within a name.
 			if t.peek() == quote {
+				if builder == nil {
+					builder = &strings.Builder{}
+					builder.WriteString(t.path[startingIndex : t.offset-1])
+				}
+
+				t.offset++
+				builder.WriteByte(quote)
 				continue
-			} else {
-				break ScanLoop
 			}
+
+			if builder == nil {
+				return t.path[startingIndex : t.offset-1], nil
+			}
+
+			return builder.String(), nil
 		case '\\':
-			// If there are two of the quotes in a row we want to consider that
-			// an escape.
-			if t.peek() == quote {
-				continue
+			if builder == nil {
+				builder = &strings.Builder{}
+				builder.WriteString(t.path[startingIndex : t.offset-1])
+			}
+
+			escaped, err := t.tokenizeEscape()
+			if err != nil {
+				return "", err
+			}
+
+			builder.WriteRune(escaped)
+		default:
+			if builder != nil {
+				builder.WriteByte(character)
 			}
 		}
 	}
+}
 
-	return t.path[startingIndex : t.offset-1], nil
+// tokenizeEscape will parse the character after a backslash within a quoted
+// string and return the character that it represents.
+func (t *pathTokenizer) tokenizeEscape() (rune, error) {
+	character := t.scan()
+	switch character {
+	case 'b':
+		return '\b', nil
+	case 'f':
+		return '\f', nil
+	case 'n':
+		return '\n', nil
+	case 'r':
+		return '\r', nil
+	case 't':
+		return '\t', nil
+	case '/', '\\', '\'', '"':
+		return rune(character), nil
+	case 'u':
+		first, err := t.tokenizeHexCharacter()
+		if err != nil {
+			return 0, err
+		}
+
+		if !utf16.IsSurrogate(first) {
+			return first, nil
+		}
+
+		// Characters outside of the basic multilingual plane are escaped as a
+		// pair of surrogates, the second one must follow immediately.
+		if t.scan() != '\\' || t.scan() != 'u' {
+			return 0, errors.Errorf("expected low surrogate after '\\u%04x'", first)
+		}
+
+		second, err := t.tokenizeHexCharacter()
+		if err != nil {
+			return 0, err
+		}
+
+		combined := utf16.DecodeRune(first, second)
+		if combined == unicode.ReplacementChar {
+			return 0, errors.Errorf("invalid surrogate pair '\\u%04x\\u%04x'", first, second)
+		}
+
+		return combined, nil
+	case 0:
+		return 0, errors.Errorf("unexpected eof parsing string")
+	default:
+		return 0, errors.Errorf("invalid escape '\\%s'", string(character))
+	}
+}
+
+// tokenizeHexCharacter will parse the four hex digits of a \u escape.
+func (t *pathTokenizer) tokenizeHexCharacter() (rune, error) {
+	if t.offset+4 > t.len {
+		return 0, errors.Errorf("unexpected eof parsing unicode escape")
+	}
+
+	str := t.path[t.offset : t.offset+4]
+	value, err := strconv.ParseUint(str, 16, 16)
+	if err != nil {
+		return 0, errors.Errorf("invalid unicode escape '\\u%s'", str)
+	}
+	t.offset += 4
+
+	return rune(value), nil
 }
 
 func (t *pathTokenizer) tokenizeString() (pathToken, error) {
 	startingIndex := t.offset
 
-	for character := t.peek(); t.isStringPart(character); character = t.scanAndPeek() {
+	for character := t.peek(); t.isStringContinuation(character); character = t.scanAndPeek() {
 	}
 
 	str := t.path[startingIndex:t.offset]
@@ -312,8 +426,18 @@ func (t *pathTokenizer) tokenizeString() (pathToken, error) {
 	return stringToken(str), nil
 }
 
+// isStringPart returns true if the character can start a string. Any non-ASCII
+// character can be part of a string, those bytes are always >= 0x80.
 func (t *pathTokenizer) isStringPart(character byte) bool {
 	return (character >= 'a' && character <= 'z') ||
 		(character >= 'A' && character <= 'Z') ||
-		character == '_'
+		character == '_' ||
+		character >= 0x80
+}
+
+// isStringContinuation returns true if the character can be part of a string
+// after the first character. Strings cannot start with a digit, otherwise
+// they would be tokenized as numbers.
+func (t *pathTokenizer) isStringContinuation(character byte) bool {
+	return t.isStringPart(character) || (character >= '0' && character <= '9')
 }
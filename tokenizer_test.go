@@ -51,4 +51,46 @@ func TestPathTokenizer_Tokenize(t *testing.T) {
 		assert.Error(t, err)
 		assert.Empty(t, tokens)
 	})
+
+	t.Run("escapes", func(t *testing.T) {
+		strings := map[string]string{
+			`'it\'s'`:        "it's",
+			`"say \"hi\""`:   `say "hi"`,
+			`'back\\slash'`:  `back\slash`,
+			`'\b\f\n\r\t\/'`: "\b\f\n\r\t/",
+			`'\u00e9'`:       "é",
+			`'\ud83d\ude42'`: "🙂",
+			`"it's"`:         "it's",
+			`'plain'`:        "plain",
+			`'it''s'`:        "it's",
+			`"say ""hi"""`:   `say "hi"`,
+			`'a'''`:          "a'",
+			`''''`:           "'",
+		}
+
+		for input, expected := range strings {
+			tokenizer := newPathTokenizer(input)
+			tokens, err := tokenizer.Tokenize()
+			assert.NoError(t, err, input)
+			if assert.Len(t, tokens, 1, input) {
+				assert.EqualValues(t, expected, tokens[0], input)
+			}
+		}
+	})
+
+	t.Run("invalid escapes", func(t *testing.T) {
+		paths := []string{
+			`'\x'`,
+			`'\u12'`,
+			`'\uzzzz'`,
+			`'\ud83d'`,
+			`'trailing\`,
+		}
+
+		for _, path := range paths {
+			tokenizer := newPathTokenizer(path)
+			_, err := tokenizer.Tokenize()
+			assert.Error(t, err, path)
+		}
+	})
 }
@@ -1,5 +1,10 @@
 package jsonpath
 
+import (
+	"strconv"
+	"strings"
+)
+
 type (
 	pathToken interface {
 		PathToken()
@@ -8,38 +13,69 @@ type (
 	characterToken          byte
 	whitespaceToken         byte
 	comparisonToken         string
+	logicalToken            string
 	stringToken             string
 	doubleQuotedStringToken string
 	singleQuotedStringToken string
 	nullToken               struct{}
 	booleanToken            bool
 	integerToken            int64
-	decimalToken            float64
+
+	// numberToken is a number that is not an int64, like a decimal or a very
+	// large integer. It is kept as it was written so that it is exact.
+	numberToken string
 )
 
 var (
 	_ pathToken = characterToken(0)
 	_ pathToken = whitespaceToken(0)
 	_ pathToken = comparisonToken("")
+	_ pathToken = logicalToken("")
 	_ pathToken = stringToken("")
 	_ pathToken = doubleQuotedStringToken("")
 	_ pathToken = singleQuotedStringToken("")
 	_ pathToken = nullToken{}
 	_ pathToken = booleanToken(false)
 	_ pathToken = integerToken(0)
-	_ pathToken = decimalToken(0)
+	_ pathToken = numberToken("")
 )
 
 func (c characterToken) PathToken()          {}
 func (w whitespaceToken) PathToken()         {}
 func (c comparisonToken) PathToken()         {}
+func (l logicalToken) PathToken()            {}
 func (s stringToken) PathToken()             {}
 func (d doubleQuotedStringToken) PathToken() {}
 func (s singleQuotedStringToken) PathToken() {}
 func (n nullToken) PathToken()               {}
 func (b booleanToken) PathToken()            {}
 func (i integerToken) PathToken()            {}
-func (d decimalToken) PathToken()            {}
+func (n numberToken) PathToken()             {}
+
+func (c characterToken) String() string {
+	if c == eof {
+		return "eof"
+	}
+
+	return string(c)
+}
+
+// The tokens are written as they appear in a path so that they can be used in
+// error messages.
+
+func (w whitespaceToken) String() string {
+	return strings.Trim(strconv.QuoteRune(rune(w)), "'")
+}
+
+func (c comparisonToken) String() string         { return string(c) }
+func (l logicalToken) String() string            { return string(l) }
+func (s stringToken) String() string             { return string(s) }
+func (d doubleQuotedStringToken) String() string { return string(d) }
+func (s singleQuotedStringToken) String() string { return string(s) }
+func (n nullToken) String() string               { return "null" }
+func (b booleanToken) String() string            { return strconv.FormatBool(bool(b)) }
+func (i integerToken) String() string            { return strconv.FormatInt(int64(i), 10) }
+func (n numberToken) String() string             { return string(n) }
 
 const (
 	eof          characterToken = 0
@@ -74,3 +110,8 @@ const (
 	greaterThan          comparisonToken = ">"
 	greaterThanOrEqualTo comparisonToken = ">="
 )
+
+const (
+	logicalAnd logicalToken = "&&"
+	logicalOr  logicalToken = "||"
+)
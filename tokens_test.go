@@ -11,12 +11,13 @@ func TestTokenImplementation(t *testing.T) {
 		characterToken(0),
 		whitespaceToken(0),
 		comparisonToken(""),
+		logicalToken(""),
 		stringToken(""),
 		doubleQuotedStringToken(""),
 		nullToken{},
 		booleanToken(false),
 		integerToken(0),
-		decimalToken(0),
+		numberToken(""),
 	}
 
 	for _, token := range tokens {
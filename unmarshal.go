@@ -0,0 +1,124 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// structTag is the name of the struct tag that holds the jsonpath of a field.
+const structTag = "jsonpath"
+
+type (
+	// structQueries are the compiled jsonpaths of the tagged fields of a
+	// struct type. The jsonpaths are keyed by the name of their field.
+	structQueries struct {
+		set    *QuerySet
+		fields []structField
+	}
+
+	structField struct {
+		name  string
+		path  string
+		index []int
+	}
+)
+
+// structCache keeps the structQueries of each struct type that has been
+// unmarshalled, so that their jsonpaths are only compiled once.
+var structCache sync.Map // map[reflect.Type]*structQueries
+
+// Unmarshal will evaluate the jsonpaths in the `jsonpath` tags of the fields
+// of the struct that v points to, and decode the results into those fields
+// using the rules of json.Unmarshal. The json is only parsed once for all of
+// the fields.
+//
+//	type Event struct {
+//		User  string   `jsonpath:"$.actor.login"`
+//		Repos []string `jsonpath:"$.payload..repo.name"`
+//	}
+//
+// Slice fields receive every result of their jsonpath, any other field must
+// match at most one value. Fields whose jsonpath does not match anything are
+// left unchanged. Fields without a tag, or with a tag of "-", are ignored.
+func Unmarshal(data []byte, v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("v must be a non-nil pointer to a struct, got %T", v)
+	}
+
+	queries, err := structQueriesOf(value.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	results, err := queries.set.rawMatches(data)
+	if err != nil {
+		return err
+	}
+
+	target := value.Elem()
+	for _, field := range queries.fields {
+		if err := field.decode(target.FieldByIndex(field.index), results[field.name]); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal %s into field %s.%s", field.path, target.Type(), field.name)
+		}
+	}
+
+	return nil
+}
+
+// structQueriesOf will return the compiled jsonpaths of the struct type,
+// compiling them if they are not in the structCache.
+func structQueriesOf(structType reflect.Type) (*structQueries, error) {
+	if cached, ok := structCache.Load(structType); ok {
+		return cached.(*structQueries), nil
+	}
+
+	queries := &structQueries{}
+	paths := map[string]string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		path, ok := field.Tag.Lookup(structTag)
+		if !ok || path == "-" {
+			continue
+		}
+
+		if field.PkgPath != "" {
+			return nil, errors.Errorf("field %s.%s has a jsonpath but is not exported", structType, field.Name)
+		}
+
+		paths[field.Name] = path
+		queries.fields = append(queries.fields, structField{
+			name:  field.Name,
+			path:  path,
+			index: field.Index,
+		})
+	}
+
+	set, err := NewQuerySet(paths)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid jsonpath in %s", structType)
+	}
+	queries.set = set
+
+	// If another goroutine compiled the same type first then use theirs.
+	cached, _ := structCache.LoadOrStore(structType, queries)
+
+	return cached.(*structQueries), nil
+}
+
+// decode will decode the matches into the field. Slices receive every match,
+// anything else is left unchanged if there are no matches.
+func (f structField) decode(field reflect.Value, matches []json.RawMessage) error {
+	if isResultSlice(field.Type()) {
+		return decodeAll(matches, field)
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+
+	return decodeOne(matches, field.Addr().Interface())
+}
@@ -0,0 +1,145 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const EventJson = `{
+	"actor": {"login": "octocat", "id": 1234567890123456789},
+	"payload": {
+		"commits": [
+			{"repo": {"name": "hello-world"}},
+			{"repo": {"name": "spoon-knife"}}
+		]
+	},
+	"public": true
+}`
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("fields", func(t *testing.T) {
+		type event struct {
+			User     string          `jsonpath:"$.actor.login"`
+			ID       int64           `jsonpath:"$.actor.id"`
+			Repos    []string        `jsonpath:"$.payload..repo.name"`
+			Public   bool            `jsonpath:"$.public"`
+			Actor    json.RawMessage `jsonpath:"$.actor"`
+			Missing  string          `jsonpath:"$.missing"`
+			Ignored  string          `jsonpath:"-"`
+			Untagged string
+		}
+
+		ev := event{
+			Missing: "unchanged",
+		}
+		require.NoError(t, Unmarshal([]byte(EventJson), &ev))
+		assert.Equal(t, event{
+			User:    "octocat",
+			ID:      1234567890123456789,
+			Repos:   []string{"hello-world", "spoon-knife"},
+			Public:  true,
+			Actor:   json.RawMessage(`{"login": "octocat", "id": 1234567890123456789}`),
+			Missing: "unchanged",
+		}, ev)
+	})
+
+	t.Run("struct fields", func(t *testing.T) {
+		type repo struct {
+			Name string `json:"name"`
+		}
+		type event struct {
+			First repo   `jsonpath:"$.payload.commits[0].repo"`
+			All   []repo `jsonpath:"$..repo"`
+		}
+
+		var ev event
+		require.NoError(t, Unmarshal([]byte(EventJson), &ev))
+		assert.Equal(t, "hello-world", ev.First.Name)
+		assert.Len(t, ev.All, 2)
+	})
+
+	t.Run("null array", func(t *testing.T) {
+		// A field whose path goes through a null or a scalar where an array
+		// is expected does not match, and the other fields are still set.
+		type event struct {
+			User string `jsonpath:"$.actor.login"`
+			SHA  string `jsonpath:"$.payload.commits[0].sha"`
+			Tags string `jsonpath:"$.payload.tags[0:1]"`
+		}
+
+		ev := event{
+			SHA: "unchanged",
+		}
+		data := []byte(`{"actor": {"login": "octocat"}, "payload": {"commits": null, "tags": "v1"}}`)
+		require.NoError(t, Unmarshal(data, &ev))
+		assert.Equal(t, event{
+			User: "octocat",
+			SHA:  "unchanged",
+		}, ev)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		type event struct {
+			User int `jsonpath:"$.actor.login"`
+		}
+
+		var ev event
+		err := Unmarshal([]byte(EventJson), &ev)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to unmarshal $.actor.login into field jsonpath.event.User")
+	})
+
+	t.Run("multiple matches", func(t *testing.T) {
+		type event struct {
+			Repo string `jsonpath:"$..repo.name"`
+		}
+
+		var ev event
+		err := Unmarshal([]byte(EventJson), &ev)
+		assert.True(t, errors.Is(err, ErrMultipleMatches))
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		type event struct {
+			User string `jsonpath:"$.actor["`
+		}
+
+		var ev event
+		err := Unmarshal([]byte(EventJson), &ev)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid jsonpath in jsonpath.event")
+	})
+
+	t.Run("unexported field", func(t *testing.T) {
+		type event struct {
+			user string `jsonpath:"$.actor.login"`
+		}
+
+		var ev event
+		err := Unmarshal([]byte(EventJson), &ev)
+		assert.EqualError(t, err, "field jsonpath.event.user has a jsonpath but is not exported")
+		assert.Empty(t, ev.user)
+	})
+
+	t.Run("not a struct pointer", func(t *testing.T) {
+		var user string
+		err := Unmarshal([]byte(EventJson), user)
+		assert.EqualError(t, err, "v must be a non-nil pointer to a struct, got string")
+
+		err = Unmarshal([]byte(EventJson), &user)
+		assert.EqualError(t, err, "v must be a non-nil pointer to a struct, got *string")
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		type event struct {
+			User string `jsonpath:"$.actor.login"`
+		}
+
+		var ev event
+		assert.Error(t, Unmarshal([]byte(`{"actor":`), &ev))
+	})
+}
@@ -0,0 +1,449 @@
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// YAMLMatch is a value that was matched by a jsonpath in a YAML stream,
+	// along with where it was found. Document is the index of the document
+	// in the stream, Path is the normalized path of the value within that
+	// document, and Line and Column are the position of the value in the
+	// source starting at 1.
+	YAMLMatch struct {
+		Document int
+		Path     string
+		Value    interface{}
+		Line     int
+		Column   int
+	}
+
+	// yamlConverter converts the nodes of a YAML document into the values
+	// that a jsonpath is evaluated against.
+	yamlConverter struct {
+		numbers NumberMode
+
+		// anchors are the values of the anchored nodes that have already been
+		// converted, so that aliases are only converted once. The value of an
+		// anchor is nil while it is being converted.
+		anchors map[*yaml.Node]*jsonNode
+	}
+)
+
+// YAMLDecoder is a Decoder for YAML 1.2 documents, so that jsonpaths can be
+// evaluated against YAML the same way that they are against json. Aliases are
+// replaced by the value of their anchor and merge keys are merged into their
+// mapping. Mapping keys are always decoded as strings. A stream of several
+// documents cannot be decoded, use Evaluator.EvaluateYAML instead.
+var YAMLDecoder Decoder = yamlDecoder{}
+
+type yamlDecoder struct{}
+
+func (yamlDecoder) Decode(data []byte, numbers NumberMode) (interface{}, error) {
+	documents, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(documents) {
+	case 0:
+		return nil, nil
+	case 1:
+		return convertYAML(documents[0], numbers)
+	default:
+		return nil, errors.Errorf("yaml stream has %d documents but only one can be decoded", len(documents))
+	}
+}
+
+// EvaluateYAML will run the compiled jsonpath against each document of the
+// provided YAML stream and return the results of all of them in the order of
+// the documents.
+func (e *Evaluator) EvaluateYAML(data []byte) ([]interface{}, error) {
+	matches, err := e.evaluateYAML(data, false)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(matches))
+	for i, match := range matches {
+		items[i] = match.Value
+	}
+
+	return items, nil
+}
+
+// EvaluateYAMLMatches is like EvaluateYAML but also returns the document,
+// path and source position of each result. Results that came from an alias
+// have the position of their anchor.
+func (e *Evaluator) EvaluateYAMLMatches(data []byte) ([]YAMLMatch, error) {
+	if err := e.checkLocations(); err != nil {
+		return nil, err
+	}
+
+	return e.evaluateYAML(data, true)
+}
+
+// evaluateYAML will evaluate the jsonpath against each document of the
+// stream, the paths and positions of the matches are only found if locate is
+// true.
+func (e *Evaluator) evaluateYAML(data []byte, locate bool) ([]YAMLMatch, error) {
+	if err := e.limits.checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	documents, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]YAMLMatch, 0)
+	for i, document := range documents {
+		value, err := convertYAML(document, e.numbers)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode yaml document %d", i)
+		}
+
+		root := locatedNode{value: value}
+		if locate {
+			root.location = rootLocation()
+		}
+
+		nodes, err := e.run(root, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, node := range nodes {
+			match := YAMLMatch{
+				Document: i,
+				Value:    node.value,
+			}
+			if locate {
+				match.Path = node.location.String()
+				if source := yamlNodeAt(document, node.location.keys()); source != nil {
+					match.Line, match.Column = source.Line, source.Column
+				}
+			}
+
+			matches = append(matches, match)
+		}
+	}
+
+	if err := e.limits.checkResults(len(matches)); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// parseYAML will parse each of the documents in the YAML stream.
+func parseYAML(data []byte) ([]*yaml.Node, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	documents := make([]*yaml.Node, 0, 1)
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal yaml input")
+		}
+
+		documents = append(documents, &document)
+	}
+}
+
+// convertYAML will convert a parsed YAML document into the values that a
+// jsonpath is evaluated against, decoding numbers using the provided mode.
+func convertYAML(document *yaml.Node, numbers NumberMode) (jsonNode, error) {
+	converter := &yamlConverter{
+		numbers: numbers,
+		anchors: map[*yaml.Node]*jsonNode{},
+	}
+
+	return converter.convert(document)
+}
+
+func (c *yamlConverter) convert(node *yaml.Node) (jsonNode, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return c.convert(node.Content[0])
+	case yaml.AliasNode:
+		return c.alias(node)
+	case yaml.SequenceNode:
+		return c.sequence(node)
+	case yaml.MappingNode:
+		return c.mapping(node)
+	case yaml.ScalarNode:
+		return c.scalar(node)
+	default:
+		return nil, errors.Errorf("unexpected yaml node at line %d", node.Line)
+	}
+}
+
+// alias will return the value of the alias's anchor, it is only converted the
+// first time that it is used.
+func (c *yamlConverter) alias(node *yaml.Node) (jsonNode, error) {
+	anchor := node.Alias
+	if value, ok := c.anchors[anchor]; ok {
+		if value == nil {
+			return nil, errors.Errorf("alias *%s at line %d refers to itself", node.Value, node.Line)
+		}
+
+		return *value, nil
+	}
+
+	c.anchors[anchor] = nil
+	value, err := c.convert(anchor)
+	if err != nil {
+		return nil, err
+	}
+	c.anchors[anchor] = &value
+
+	return value, nil
+}
+
+func (c *yamlConverter) sequence(node *yaml.Node) (jsonNode, error) {
+	array := make(jsonArray, len(node.Content))
+	for i, item := range node.Content {
+		value, err := c.convert(item)
+		if err != nil {
+			return nil, err
+		}
+
+		array[i] = value
+	}
+
+	return array, nil
+}
+
+// mapping will convert the mapping into an object. Keys that are in the
+// mapping itself take precedence over merged keys, and earlier merges take
+// precedence over later ones.
+func (c *yamlConverter) mapping(node *yaml.Node) (jsonNode, error) {
+	object := make(jsonObject, len(node.Content)/2)
+	merges := make([]*yaml.Node, 0)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if isYAMLMerge(key) {
+			merges = append(merges, value)
+			continue
+		}
+
+		name, err := yamlKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if object[name], err = c.convert(value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, merge := range merges {
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+
+		for _, source := range sources {
+			value, err := c.convert(source)
+			if err != nil {
+				return nil, err
+			}
+
+			merged, ok := value.(jsonObject)
+			if !ok {
+				return nil, errors.Errorf("cannot merge a non-mapping into the mapping at line %d", node.Line)
+			}
+
+			for name, item := range merged {
+				if _, ok := object[name]; !ok {
+					object[name] = item
+				}
+			}
+		}
+	}
+
+	return object, nil
+}
+
+func (c *yamlConverter) scalar(node *yaml.Node) (jsonNode, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!int", "!!float":
+		// Decimal numbers are converted from the text that was written so
+		// that no precision is lost, the same as numbers in json.
+		if number, ok := c.decimal(node.Value); ok {
+			return number, nil
+		}
+
+		fallthrough
+	case "!!bool":
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode '%s' at line %d", node.Value, node.Line)
+		}
+
+		switch v := value.(type) {
+		case int:
+			return int64Node(int64(v), c.numbers), nil
+		case int64:
+			return int64Node(v, c.numbers), nil
+		case uint64:
+			return uint64Node(v, c.numbers), nil
+		case float64:
+			return float64Node(v, c.numbers), nil
+		default:
+			return value, nil
+		}
+	default:
+		// Strings, timestamps and anything with a custom tag are left as
+		// they were written.
+		return node.Value, nil
+	}
+}
+
+// decimal will convert the text of a decimal number into the number mode of
+// the converter. YAML allows underscores, a leading plus and a dot without
+// any digits on one side, these are removed so that the text is a json
+// number. False is returned if the text is not a decimal number, such as hex
+// numbers or infinity.
+func (c *yamlConverter) decimal(text string) (jsonNode, bool) {
+	text = strings.ReplaceAll(text, "_", "")
+	text = strings.TrimPrefix(text, "+")
+
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	if strings.HasPrefix(text, ".") {
+		text = "0" + text
+	}
+	if dot := strings.IndexByte(text, '.'); dot >= 0 && (dot+1 == len(text) || text[dot+1] == 'e' || text[dot+1] == 'E') {
+		text = text[:dot] + text[dot+1:]
+	}
+	text = sign + text
+
+	if text == "" || (text[0] != '-' && (text[0] < '0' || text[0] > '9')) || !json.Valid([]byte(text)) {
+		return nil, false
+	}
+
+	switch c.numbers {
+	case JSONNumbers:
+		return json.Number(text), true
+	case BigNumbers:
+		number, err := parseBigNumber(text)
+		return number, err == nil
+	default:
+		number, err := strconv.ParseFloat(text, 64)
+		return number, err == nil
+	}
+}
+
+// yamlKey returns the key of a mapping as a string.
+func yamlKey(key *yaml.Node) (string, error) {
+	if key.Kind == yaml.AliasNode {
+		key = key.Alias
+	}
+
+	if key.Kind != yaml.ScalarNode {
+		return "", errors.Errorf("mapping key at line %d must be a scalar", key.Line)
+	}
+
+	return key.Value, nil
+}
+
+// isYAMLMerge returns true if the key is a merge key, `<<`.
+func isYAMLMerge(key *yaml.Node) bool {
+	return key.Kind == yaml.ScalarNode && key.ShortTag() == "!!merge"
+}
+
+// yamlNodeAt will return the node at the keys of a location within the
+// document, or nil if it cannot be found.
+func yamlNodeAt(node *yaml.Node, keys []interface{}) *yaml.Node {
+	node = resolveYAML(node)
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			node = yamlMappingValue(node, k)
+		case int:
+			if node.Kind != yaml.SequenceNode || k >= len(node.Content) {
+				return nil
+			}
+
+			node = resolveYAML(node.Content[k])
+		}
+
+		if node == nil {
+			return nil
+		}
+	}
+
+	return node
+}
+
+// yamlMappingValue will return the value of the key in the mapping, looking in
+// its merges if the mapping does not have the key itself.
+func yamlMappingValue(node *yaml.Node, name string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	// Later keys replace earlier ones when the mapping is converted.
+	merges := make([]*yaml.Node, 0)
+	for i := len(node.Content) - 2; i >= 0; i -= 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if isYAMLMerge(key) {
+			merges = append([]*yaml.Node{resolveYAML(value)}, merges...)
+			continue
+		}
+
+		if key, err := yamlKey(key); err == nil && key == name {
+			return resolveYAML(value)
+		}
+	}
+
+	for _, merge := range merges {
+		sources := []*yaml.Node{merge}
+		if merge.Kind == yaml.SequenceNode {
+			sources = merge.Content
+		}
+
+		for _, source := range sources {
+			if value := yamlMappingValue(resolveYAML(source), name); value != nil {
+				return value
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveYAML will return the content of a document or the anchor of an
+// alias.
+func resolveYAML(node *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) > 0:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
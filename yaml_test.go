@@ -0,0 +1,205 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ServicesYaml = `defaults: &defaults
+  replicas: 2
+  image: base:1.0
+services:
+  - name: api
+    <<: *defaults
+    replicas: 4
+  - name: worker
+    <<: *defaults
+    ports: [8080, 0x1F90]
+enabled: yes
+timeout: 1.5
+---
+services:
+  - name: cron
+    replicas: 1
+`
+
+func TestWithDecoder(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		eval, err := NewEvaluator("$.services[?@.replicas > 1].name", WithDecoder(YAMLDecoder))
+		require.NoError(t, err)
+
+		result, err := eval.Evaluate([]byte("services:\n  - {name: api, replicas: 4}\n  - {name: cron, replicas: 1}\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"api"}, result)
+	})
+
+	t.Run("scannable paths are decoded", func(t *testing.T) {
+		eval, err := NewEvaluator("$.a.b", WithDecoder(YAMLDecoder))
+		require.NoError(t, err)
+
+		result, err := eval.Evaluate([]byte("a:\n  b: 1\n"))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{float64(1)}, result)
+
+		first, err := eval.First([]byte("a:\n  b: 2\n"))
+		require.NoError(t, err)
+		assert.Equal(t, float64(2), first)
+	})
+
+	t.Run("json decoder", func(t *testing.T) {
+		eval, err := NewEvaluator("$.a", WithDecoder(JSONDecoder))
+		require.NoError(t, err)
+
+		result, err := eval.EvaluateRaw([]byte(`{"a": 1.50}`))
+		require.NoError(t, err)
+		assert.Equal(t, []json.RawMessage{json.RawMessage(`1.50`)}, result)
+	})
+
+	t.Run("no raw results", func(t *testing.T) {
+		eval, err := NewEvaluator("$.a", WithDecoder(YAMLDecoder))
+		require.NoError(t, err)
+
+		_, err = eval.EvaluateRaw([]byte("a: 1"))
+		assert.Error(t, err)
+
+		set, err := NewQuerySet(map[string]string{"a": "$.a"}, WithDecoder(YAMLDecoder))
+		require.NoError(t, err)
+
+		_, err = set.EvaluateRaw([]byte("a: 1"))
+		assert.Error(t, err)
+
+		results, err := set.Evaluate([]byte("a: 1"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]interface{}{"a": {float64(1)}}, results)
+	})
+
+	t.Run("multiple documents", func(t *testing.T) {
+		_, err := YAMLDecoder.Decode([]byte(ServicesYaml), Float64Numbers)
+		assert.Error(t, err)
+	})
+}
+
+func TestEvaluator_EvaluateYAML(t *testing.T) {
+	t.Run("documents", func(t *testing.T) {
+		result, err := MustCompile("$.services[*].name").EvaluateYAML([]byte(ServicesYaml))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"api", "worker", "cron"}, result)
+	})
+
+	t.Run("merge keys", func(t *testing.T) {
+		result, err := MustCompile("$.services[*].replicas").EvaluateYAML([]byte(ServicesYaml))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{float64(4), float64(2), float64(1)}, result)
+
+		result, err = MustCompile("$.services[?@.image == 'base:1.0'].name").EvaluateYAML([]byte(ServicesYaml))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"api", "worker"}, result)
+	})
+
+	t.Run("scalars", func(t *testing.T) {
+		result, err := MustCompile("$['enabled', 'timeout']").EvaluateYAML([]byte(ServicesYaml))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"yes", 1.5}, result)
+
+		result, err = MustCompile("$.services[1].ports").EvaluateYAML([]byte(ServicesYaml))
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{[]interface{}{float64(8080), float64(8080)}}, result)
+
+		result, err = MustCompile("$.*").EvaluateYAML([]byte("a: ~\nb: true\nc: '12'\n1: one"))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []interface{}{nil, true, "12", "one"}, result)
+	})
+
+	t.Run("number modes", func(t *testing.T) {
+		data := []byte("id: 98765432109876543210\nsmall: 0x10\ntotal: 0.1")
+
+		result, err := MustCompile("$['small', 'total']", WithNumberMode(JSONNumbers)).EvaluateYAML(data)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{json.Number("16"), json.Number("0.1")}, result)
+
+		result, err = MustCompile("$['small', 'total']", WithNumberMode(BigNumbers)).EvaluateYAML(data)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, big.NewInt(16), result[0])
+		assert.Equal(t, "0.1", result[1].(*big.Float).Text('g', 10))
+	})
+
+	t.Run("numbers as written", func(t *testing.T) {
+		data := []byte("exact: 0.10000000000000001\nlarge: 12345678901234567890123\nnegative: -98765432109876543210\nplus: +1_000.5\nshort: .5\nint: !!int 12345678901234567890123\n")
+		path := "$['exact', 'large', 'negative', 'plus', 'short', 'int']"
+
+		result, err := MustCompile(path, WithNumberMode(JSONNumbers)).EvaluateYAML(data)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			json.Number("0.10000000000000001"),
+			json.Number("12345678901234567890123"),
+			json.Number("-98765432109876543210"),
+			json.Number("1000.5"),
+			json.Number("0.5"),
+			json.Number("12345678901234567890123"),
+		}, result)
+
+		result, err = MustCompile(path, WithNumberMode(BigNumbers)).EvaluateYAML(data)
+		require.NoError(t, err)
+		require.Len(t, result, 6)
+		assert.Equal(t, "0.10000000000000001", result[0].(*big.Float).Text('g', 20))
+		large, _ := new(big.Int).SetString("12345678901234567890123", 10)
+		assert.Equal(t, large, result[1])
+		negative, _ := new(big.Int).SetString("-98765432109876543210", 10)
+		assert.Equal(t, negative, result[2])
+		assert.Equal(t, "1000.5", result[3].(*big.Float).Text('g', 10))
+		assert.Equal(t, "0.5", result[4].(*big.Float).Text('g', 10))
+		assert.Equal(t, large, result[5])
+
+		result, err = MustCompile("$['exact', 'large']").EvaluateYAML(data)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{0.1, 1.2345678901234568e+22}, result)
+	})
+
+	t.Run("recursive alias", func(t *testing.T) {
+		_, err := MustCompile("$").EvaluateYAML([]byte("a: &a [*a]"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid yaml", func(t *testing.T) {
+		_, err := MustCompile("$").EvaluateYAML([]byte("a: [1, 2"))
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed yaml", func(t *testing.T) {
+		// This used to panic inside of the yaml parser, CVE-2022-28948.
+		data := []byte("0: [:!00 \xef")
+
+		assert.NotPanics(t, func() {
+			_, err := MustCompile("$").EvaluateYAML(data)
+			assert.Error(t, err)
+
+			_, err = MustCompile("$").EvaluateYAMLMatches(data)
+			assert.Error(t, err)
+
+			_, err = YAMLDecoder.Decode(data, Float64Numbers)
+			assert.Error(t, err)
+		})
+	})
+}
+
+func TestEvaluator_EvaluateYAMLMatches(t *testing.T) {
+	matches, err := MustCompile("$.services[*].image").EvaluateYAMLMatches([]byte(ServicesYaml))
+	require.NoError(t, err)
+	assert.Equal(t, []YAMLMatch{
+		{Document: 0, Path: "$['services'][0]['image']", Value: "base:1.0", Line: 3, Column: 10},
+		{Document: 0, Path: "$['services'][1]['image']", Value: "base:1.0", Line: 3, Column: 10},
+	}, matches)
+
+	matches, err = MustCompile("$..name").EvaluateYAMLMatches([]byte(ServicesYaml))
+	require.NoError(t, err)
+	assert.Equal(t, []YAMLMatch{
+		{Document: 0, Path: "$['services'][0]['name']", Value: "api", Line: 5, Column: 11},
+		{Document: 0, Path: "$['services'][1]['name']", Value: "worker", Line: 8, Column: 11},
+		{Document: 1, Path: "$['services'][0]['name']", Value: "cron", Line: 15, Column: 11},
+	}, matches)
+}